@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"jogo/shared"
+)
+
+const (
+	chatHistorico = 100 // Mensagens guardadas no cliente, para rolar o painel
+	chatLinhas    = 5   // Mensagens visíveis no painel
+)
+
+// Trata as teclas do chat: abrir, digitar, enviar, cancelar e rolar o painel
+func chatExecutarAcao(ev EventoTeclado, jogo *Jogo) {
+	switch ev.Tipo {
+	case "chat":
+		jogo.Digitando = true
+		jogo.ChatTexto = nil
+	case "chat-letra":
+		jogo.ChatTexto = append(jogo.ChatTexto, ev.Tecla)
+	case "chat-apagar":
+		if len(jogo.ChatTexto) > 0 {
+			jogo.ChatTexto = jogo.ChatTexto[:len(jogo.ChatTexto)-1]
+		}
+	case "chat-enviar":
+		if len(jogo.ChatTexto) > 0 {
+			go enviarChat(string(jogo.ChatTexto))
+		}
+		jogo.Digitando = false
+		jogo.ChatTexto = nil
+	case "chat-cancelar":
+		jogo.Digitando = false
+		jogo.ChatTexto = nil
+	case "chat-subir":
+		jogo.ChatRolagem = min(jogo.ChatRolagem+1, max(len(jogo.Chat)-chatLinhas, 0))
+	case "chat-descer":
+		jogo.ChatRolagem = max(jogo.ChatRolagem-1, 0)
+	}
+}
+
+// Envia uma mensagem; ela volta para nós junto com as dos outros.
+// Não reenvia: se a resposta se perdeu, a mensagem pode já estar no chat.
+func enviarChat(texto string) {
+	args := &shared.SendChatArgs{SessionToken: sessionToken, Text: texto}
+	reply := &shared.SendChatReply{}
+	if err := chamarRPC("GameService.SendChat", args, reply, 1); err != nil {
+		mapChannel <- func(j *Jogo) {
+			j.StatusMsg = fmt.Sprintf("Mensagem não enviada: %v", err)
+		}
+	}
+}
+
+// Busca as mensagens depois de since e as entrega ao mapManager
+func buscarChat(since int64) {
+	args := &shared.GetChatArgs{SessionToken: sessionToken, Since: since}
+	reply := &shared.GetChatReply{}
+	if callWithRetry("GameService.GetChat", args, reply) != nil {
+		return
+	}
+
+	mapChannel <- func(j *Jogo) {
+		for _, m := range reply.Messages {
+			// Duas buscas podem trazer a mesma mensagem
+			if m.Seq <= j.ChatSeq {
+				continue
+			}
+			j.Chat = append(j.Chat, m)
+			j.ChatSeq = m.Seq
+			// Mantém a mesma mensagem na tela se o painel estiver rolado
+			if j.ChatRolagem > 0 {
+				j.ChatRolagem++
+			}
+		}
+		if len(j.Chat) > chatHistorico {
+			j.Chat = j.Chat[len(j.Chat)-chatHistorico:]
+		}
+		j.ChatRolagem = min(j.ChatRolagem, max(len(j.Chat)-chatLinhas, 0))
+	}
+	select {
+	case renderChannel <- struct{}{}:
+	default:
+	}
+}
+
+// Busca as mensagens novas se o servidor avisou que o chat andou
+func jogoAtualizarChat(jogo *Jogo, seq int64) {
+	// O servidor reiniciou e começou o chat do zero
+	if seq < jogo.ChatSeq {
+		jogo.ChatSeq = 0
+	}
+	if seq > jogo.ChatSeq {
+		go buscarChat(jogo.ChatSeq)
+	}
+}
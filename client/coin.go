@@ -1,75 +1,44 @@
 package main
 
 import (
-	"math/rand"
-	"time"
-)
+	"fmt"
 
-func coinManager(jogo *Jogo) {
-	var existingCoin bool = false
-	var posicaoMoedaX, posicaoMoedaY int
+	"jogo/shared"
+)
 
-	spawnTicker := time.NewTicker(5 * time.Second)
-	defer spawnTicker.Stop()
+// Atualiza a moeda do mapa local com o estado vindo do servidor
+func jogoAtualizarMoeda(jogo *Jogo, moeda shared.CoinState) {
+	antiga := jogo.Moeda
 
-	for {
-		select {
-		case <-spawnTicker.C:
-			if existingCoin {
-				clearCoin(jogo, posicaoMoedaX, posicaoMoedaY)
-				existingCoin = false
-			}
-			if !jogo.PortalAtivo {
-				spawned, x, y := spawnCoin(jogo)
-				if spawned {
-					existingCoin = true
-					posicaoMoedaX = x
-					posicaoMoedaY = y
-				}
-			}
-		case <-gameOverChannel:
-			return
-		}
+	// Remove a moeda da posição antiga, se ainda estiver lá
+	if antiga.Active && (!moeda.Active || antiga.X != moeda.X || antiga.Y != moeda.Y) {
+		clearCoin(jogo, antiga.X, antiga.Y)
 	}
-}
-
-func spawnCoin(jogo *Jogo) (bool, int, int) {
-
-	maxY := len(jogo.Mapa)
-	maxX := len(jogo.Mapa[0])
-
-	// Tenta spawnar a moeda indefinidamente até achar uma posição válida
-	for {
-		// Gera uma posição aleatória para x e y
-		x := rand.Intn(maxX)
-		y := rand.Intn(maxY)
 
-		// Verifica se a posição é válida (vazio e não tangível)
-		if !jogo.Mapa[y][x].tangivel && jogo.Mapa[y][x].simbolo == Vazio.simbolo {
-
-			// Escreve o comando atualizando o mapa
-			cmd := func(jogo *Jogo) {
-				jogo.Mapa[y][x] = Moeda
-			}
+	// Desenha a moeda na nova posição
+	if moeda.Active && dentroDoMapa(jogo, moeda.X, moeda.Y) && jogo.Mapa[moeda.Y][moeda.X].simbolo == Vazio.simbolo {
+		jogo.Mapa[moeda.Y][moeda.X] = Moeda
+	}
 
-			// Envia o comando para o mapManager
-			mapChannel <- cmd
-			// Retorna a posição onde a moeda foi spawnada
-			return true, x, y
+	// Avisa quem pegou a moeda
+	if antiga.Active && !moeda.Active && moeda.LastTakenBy != 0 {
+		if moeda.LastTakenBy == myID {
+			jogo.StatusMsg = "Você pegou a moeda!"
+		} else {
+			jogo.StatusMsg = fmt.Sprintf("Jogador %d pegou a moeda!", moeda.LastTakenBy)
 		}
 	}
+	jogo.Moeda = moeda
 }
 
 func clearCoin(jogo *Jogo, x int, y int) {
-
 	// Verifica se ainda há uma moeda na posição
-	if jogo.Mapa[y][x].simbolo == Moeda.simbolo {
-
-		// Escreve o comando atualizando o mapa
-		cmd := func(jogo *Jogo) {
-			jogo.Mapa[y][x] = Vazio
-		}
-		// Envia o comando para o mapManager
-		mapChannel <- cmd
+	if dentroDoMapa(jogo, x, y) && jogo.Mapa[y][x].simbolo == Moeda.simbolo {
+		jogo.Mapa[y][x] = Vazio
 	}
 }
+
+// Verifica se (x, y) está dentro do mapa local
+func dentroDoMapa(jogo *Jogo, x, y int) bool {
+	return y >= 0 && y < len(jogo.Mapa) && x >= 0 && x < len(jogo.Mapa[y])
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/rpc"
+	"os"
+	"strings"
+	"sync"
+
+	"jogo/shared"
+
+	"golang.org/x/term"
+)
+
+const serverAddr = "localhost:12345" // Endereço do servidor RPC
+
+var (
+	clientMu     sync.Mutex  // Protege a troca de client em reconectar
+	sessionToken string      // Token recebido no Connect, identifica o jogador em todas as chamadas
+	tlsConfig    *tls.Config // Configuração do TLS, nil para TCP puro
+)
+
+// configurarTLS monta o tls.Config a partir das flags do cliente.
+// caArq confia numa CA local; pin exige que a chave do servidor tenha esse
+// SHA-256 e, sozinho, dispensa a CA.
+func configurarTLS(usar bool, caArq, pin string) (*tls.Config, error) {
+	if !usar && caArq == "" && pin == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caArq != "" {
+		dados, err := os.ReadFile(caArq)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(dados) {
+			return nil, fmt.Errorf("%s não contém certificados", caArq)
+		}
+	}
+	if pin != "" {
+		// Sem CA quem garante o servidor é só o pin, verificado abaixo
+		cfg.InsecureSkipVerify = caArq == ""
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errors.New("o servidor não apresentou certificado")
+			}
+			if got := shared.PinCertificado(cs.PeerCertificates[0]); got != pin {
+				return fmt.Errorf("o certificado do servidor não confere com o pin (recebido %s)", got)
+			}
+			return nil
+		}
+	}
+	return cfg, nil
+}
+
+// discar abre uma conexão RPC com o servidor, por TLS se configurado
+func discar() (*rpc.Client, error) {
+	if tlsConfig == nil {
+		return rpc.Dial("tcp", serverAddr)
+	}
+	conn, err := tls.Dial("tcp", serverAddr, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	return rpc.NewClient(conn), nil
+}
+
+// explicarRecusa traduz o erro do Connect numa mensagem para o jogador
+func explicarRecusa(err error) string {
+	re := shared.ParseRejectError(err)
+	if re == nil {
+		return fmt.Sprintf("Não foi possível entrar no jogo: %v", err)
+	}
+	switch re.Reason {
+	case shared.RejectServerFull:
+		return fmt.Sprintf("O servidor está cheio: %s. Tente de novo mais tarde.", re.Detail)
+	case shared.RejectBanned:
+		return "Você foi banido deste servidor."
+	case shared.RejectProtocolMismatch:
+		return fmt.Sprintf("Este cliente não é compatível com o servidor: %s. Atualize o jogo.", re.Detail)
+	case shared.RejectBadCredentials:
+		return "Usuário ou senha inválidos. Para criar a conta, rode de novo com -registrar."
+	case shared.RejectRoomNotFound:
+		msg := fmt.Sprintf("Não foi possível entrar: %s.", re.Detail)
+		// Ajuda o jogador a escolher uma sala que existe
+		reply := &shared.ListRoomsReply{}
+		if client.Call("GameService.ListRooms", &shared.ListRoomsArgs{}, reply) == nil {
+			var nomes []string
+			for _, r := range reply.Rooms {
+				nomes = append(nomes, r.Name)
+			}
+			msg += " Salas disponíveis: " + strings.Join(nomes, ", ")
+		}
+		return msg
+	}
+	return fmt.Sprintf("O servidor recusou a conexão: %s", re.Detail)
+}
+
+// lerCredenciais pergunta o que não veio por flag. A senha pode vir da
+// variável de ambiente JOGO_SENHA; no terminal é digitada sem eco.
+func lerCredenciais(usuario string) (string, string) {
+	entrada := bufio.NewReader(os.Stdin)
+	perguntar := func(texto string) string {
+		fmt.Print(texto)
+		linha, _ := entrada.ReadString('\n')
+		return strings.TrimSpace(linha)
+	}
+	if usuario == "" {
+		usuario = perguntar("Usuário: ")
+	}
+	senha, ok := os.LookupEnv("JOGO_SENHA")
+	if ok {
+		return usuario, senha
+	}
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return usuario, perguntar("Senha: ") // Entrada redirecionada, não há o que esconder
+	}
+	fmt.Print("Senha: ")
+	lida, err := term.ReadPassword(fd)
+	fmt.Println()
+	if err != nil {
+		log.Printf("Erro ao ler a senha: %v", err)
+	}
+	return usuario, string(lida)
+}
+
+// retorna a conexão RPC atual
+func rpcClient() *rpc.Client {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+	return client
+}
+
+// verifica se o erro indica que a conexão TCP caiu
+func conexaoPerdida(err error) bool {
+	return errors.Is(err, rpc.ErrShutdown) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// verifica se o erro veio do caminho até o servidor e não de uma resposta dele
+func erroDeTransporte(err error) bool {
+	var netErr net.Error
+	return conexaoPerdida(err) || errors.As(err, &netErr)
+}
+
+// reconectar abre uma nova conexão e volta ao jogo como o mesmo jogador.
+// antigo é a conexão que falhou; se outra goroutine já a trocou, não faz nada.
+func reconectar(antigo *rpc.Client) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+
+	if client != antigo {
+		return // Já reconectado
+	}
+
+	novo, err := discar()
+	if err != nil {
+		log.Printf("Erro ao reconectar: %v", err)
+		return
+	}
+
+	args := &shared.ReconnectArgs{SessionToken: sessionToken}
+	reply := &shared.ReconnectReply{}
+	if err := novo.Call("GameService.Reconnect", args, reply); err != nil {
+		log.Printf("Erro ao reconectar: %v", err)
+		novo.Close()
+		return
+	}
+
+	antigo.Close()
+	client = novo
+
+	// Continua a numeração de onde o servidor parou
+	seqMu.Lock()
+	if sequenceNumber < reply.LastSequenceNumber {
+		sequenceNumber = reply.LastSequenceNumber
+	}
+	seqMu.Unlock()
+
+	log.Printf("Reconectado. ID: %d", reply.PlayerID)
+	pos := reply.AllPlayers[reply.PlayerID]
+	mapChannel <- func(j *Jogo) {
+		j.Players = reply.AllPlayers
+		jogoCorrigirPosicao(j, pos.PosX, pos.PosY)
+		j.StatusMsg = "Reconectado ao servidor"
+	}
+}
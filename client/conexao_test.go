@@ -0,0 +1,203 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"jogo/shared"
+)
+
+// certificadosTeste gera em memória uma CA e um certificado para 127.0.0.1
+// assinado por ela. Devolve o PEM da CA, o certificado do servidor e o pin dele.
+func certificadosTeste(t *testing.T) ([]byte, tls.Certificate, string) {
+	t.Helper()
+	agora := time.Now()
+
+	caChave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caModelo := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "CA de teste"},
+		NotBefore:             agora.Add(-time.Hour),
+		NotAfter:              agora.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caModelo, caModelo, &caChave.PublicKey, caChave)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modelo := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "servidor de teste"},
+		NotBefore:    agora.Add(-time.Hour),
+		NotAfter:     agora.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, modelo, ca, &chave.PublicKey, caChave)
+	if err != nil {
+		t.Fatal(err)
+	}
+	folha, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: chave, Leaf: folha}
+	return caPEM, cert, shared.PinCertificado(folha)
+}
+
+// servidorTLSTeste aceita conexões TLS numa porta livre e completa o handshake
+func servidorTLSTeste(t *testing.T, cert tls.Certificate) string {
+	t.Helper()
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				conn.SetDeadline(time.Now().Add(5 * time.Second))
+				conn.(*tls.Conn).Handshake()
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestConfigurarTLS(t *testing.T) {
+	caPEM, cert, pin := certificadosTeste(t)
+	addr := servidorTLSTeste(t, cert)
+
+	caArq := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caArq, caPEM, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, _, pinErrado := certificadosTeste(t) // Pin de outra chave
+
+	casos := []struct {
+		nome   string
+		ca     string
+		pin    string
+		aceita bool
+		erro   string // Trecho esperado na mensagem quando recusa
+	}{
+		{nome: "CA certa", ca: caArq, aceita: true},
+		{nome: "pin certo sem CA", pin: pin, aceita: true},
+		{nome: "CA e pin certos", ca: caArq, pin: pin, aceita: true},
+		{nome: "pin errado", pin: pinErrado, erro: "não confere com o pin"},
+		{nome: "CA certa e pin errado", ca: caArq, pin: pinErrado, erro: "não confere com o pin"},
+		{nome: "sem CA nem pin", erro: "certificate"},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			cfg, err := configurarTLS(true, c.ca, c.pin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			conn, err := tls.Dial("tcp", addr, cfg)
+			if c.aceita {
+				if err != nil {
+					t.Fatalf("conexão recusada: %v", err)
+				}
+				conn.Close()
+				return
+			}
+			if err == nil {
+				conn.Close()
+				t.Fatal("conexão aceita, esperava recusa")
+			}
+			if !strings.Contains(err.Error(), c.erro) {
+				t.Errorf("erro = %v, esperava algo com %q", err, c.erro)
+			}
+		})
+	}
+}
+
+func TestConfigurarTLSDesligado(t *testing.T) {
+	cfg, err := configurarTLS(false, "", "")
+	if cfg != nil || err != nil {
+		t.Fatalf("configurarTLS = %v, %v; esperava nil, nil", cfg, err)
+	}
+}
+
+func TestConfigurarTLSCAInvalida(t *testing.T) {
+	arq := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(arq, []byte("não é PEM"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := configurarTLS(true, arq, ""); err == nil {
+		t.Fatal("aceitou um arquivo de CA sem certificados")
+	}
+}
+
+// Um cliente sem TLS não consegue falar com um servidor que exige TLS
+func TestTCPPuroContraTLS(t *testing.T) {
+	_, cert, _ := certificadosTeste(t)
+	addr := servidorTLSTeste(t, cert)
+
+	client, err := rpc.Dial("tcp", addr)
+	if err != nil {
+		return // Recusado já na conexão
+	}
+	defer client.Close()
+	var reply shared.ListRoomsReply
+	if err := client.Call("GameService.ListRooms", &shared.ListRoomsArgs{}, &reply); err == nil {
+		t.Fatal("chamada sem TLS aceita por um servidor TLS")
+	}
+}
+
+func TestErroDeTransporte(t *testing.T) {
+	casos := []struct {
+		erro       error
+		transporte bool
+	}{
+		{rpc.ErrShutdown, true},
+		{io.EOF, true},
+		{io.ErrUnexpectedEOF, true},
+		{&net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}, true},
+		{rpc.ServerError(shared.ErrSessionExpired), false},
+		{rpc.ServerError(shared.ErrChatEmpty), false},
+	}
+	for _, c := range casos {
+		if got := erroDeTransporte(c.erro); got != c.transporte {
+			t.Errorf("erroDeTransporte(%v) = %v, esperava %v", c.erro, got, c.transporte)
+		}
+	}
+}
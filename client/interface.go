@@ -6,7 +6,11 @@
 package main
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/nsf/termbox-go"
+	"jogo/shared"
 )
 
 // Define um tipo Cor para encapsuladar as cores do termbox
@@ -26,7 +30,7 @@ const (
 )
 
 type EventoTeclado struct {
-	Tipo  string // "sair", "interagir", "mover"
+	Tipo  string // "sair", "interagir", "mover", "placar", "chat", "chat-*"
 	Tecla rune   // Tecla pressionada, usada no caso de movimento
 }
 
@@ -42,18 +46,46 @@ func interfaceFinalizar() {
 	termbox.Close()
 }
 
-// Lê um evento do teclado e o traduz para um EventoTeclado
-func interfaceLerEventoTeclado() EventoTeclado {
+// Lê um evento do teclado e o traduz para um EventoTeclado.
+// Enquanto digitando no chat, as teclas viram texto em vez de comandos.
+func interfaceLerEventoTeclado(digitando bool) EventoTeclado {
 	ev := termbox.PollEvent()
 	if ev.Type != termbox.EventKey {
 		return EventoTeclado{}
 	}
+	if digitando {
+		switch ev.Key {
+		case termbox.KeyEsc:
+			return EventoTeclado{Tipo: "chat-cancelar"}
+		case termbox.KeyEnter:
+			return EventoTeclado{Tipo: "chat-enviar"}
+		case termbox.KeyBackspace, termbox.KeyBackspace2:
+			return EventoTeclado{Tipo: "chat-apagar"}
+		case termbox.KeySpace:
+			return EventoTeclado{Tipo: "chat-letra", Tecla: ' '}
+		}
+		if ev.Ch == 0 {
+			return EventoTeclado{}
+		}
+		return EventoTeclado{Tipo: "chat-letra", Tecla: ev.Ch}
+	}
+	switch ev.Key {
+	case termbox.KeyEnter:
+		return EventoTeclado{Tipo: "chat"}
+	case termbox.KeyPgup:
+		return EventoTeclado{Tipo: "chat-subir"}
+	case termbox.KeyPgdn:
+		return EventoTeclado{Tipo: "chat-descer"}
+	}
 	if ev.Key == termbox.KeyEsc {
 		return EventoTeclado{Tipo: "sair"}
 	}
 	if ev.Ch == 'e' {
 		return EventoTeclado{Tipo: "interagir"}
 	}
+	if ev.Key == termbox.KeyTab {
+		return EventoTeclado{Tipo: "placar"}
+	}
 	return EventoTeclado{Tipo: "mover", Tecla: ev.Ch}
 }
 
@@ -68,20 +100,37 @@ func interfaceDesenharJogo(jogo *Jogo) {
 		}
 	}
 
+	// Desenha os inimigos, que andam pelo mapa
+	for _, inimigo := range jogo.Inimigos {
+		interfaceDesenharElemento(inimigo.X, inimigo.Y, Inimigo)
+	}
+
 	if jogo.Players != nil {
 		for id, playerState := range jogo.Players {
-			if id != myID { // Não desenha nosso próprio 'fantasma'
+			if id != myID && !playerState.Dead { // Não desenha nosso próprio 'fantasma' nem os mortos
 				interfaceDesenharElemento(playerState.PosX, playerState.PosY, PersonagemRemoto)
 			}
 		}
 	}
 
 	// Desenha o personagem local (por cima)
-	interfaceDesenharElemento(jogo.PosX, jogo.PosY, Personagem)
+	if jogo.Players[myID].Dead {
+		interfaceDesenharElemento(jogo.PosX, jogo.PosY, PersonagemMorto)
+	} else {
+		interfaceDesenharElemento(jogo.PosX, jogo.PosY, Personagem)
+	}
 
 	// Desenha a barra de status
 	interfaceDesenharBarraDeStatus(jogo, jogo.StatusMsg)
 
+	// Desenha o painel do chat abaixo da barra de status
+	interfaceDesenharChat(jogo)
+
+	// Desenha o placar por cima do mapa, se aberto
+	if jogo.MostrarPlacar {
+		interfaceDesenharPlacar(jogo)
+	}
+
 	// Força a atualização do terminal
 	interfaceAtualizarTela()
 }
@@ -108,9 +157,103 @@ func interfaceDesenharBarraDeStatus(jogo *Jogo, statusMsg string) {
 		termbox.SetCell(i, len(jogo.Mapa)+1, c, CorTexto, CorPadrao)
 	}
 
+	// Vida e placar do jogador local
+	eu := jogo.Players[myID]
+	vida := strings.Repeat("♥", eu.HP) + strings.Repeat("♡", max(shared.MaxHP-eu.HP, 0))
+	if eu.Dead {
+		vida = "MORTO"
+	}
+	placar := fmt.Sprintf("Vida: %s  Moedas: %d", vida, eu.Score)
+	for i, c := range placar {
+		termbox.SetCell(i, len(jogo.Mapa)+2, c, CorAmarelo, CorPadrao)
+	}
+
 	// Instruções fixas
-	msg := "Use WASD para mover e E para interagir. ESC para sair."
+	msg := "Use WASD para mover e E para interagir. TAB mostra o placar, ENTER abre o chat. ESC para sair."
 	for i, c := range msg {
 		termbox.SetCell(i, len(jogo.Mapa)+3, c, CorTexto, CorPadrao)
 	}
 }
+
+// Desenha as últimas mensagens do chat abaixo da barra de status,
+// e a mensagem sendo digitada
+func interfaceDesenharChat(jogo *Jogo) {
+	y0 := len(jogo.Mapa) + 5
+
+	fim := max(len(jogo.Chat)-jogo.ChatRolagem, 0)
+	inicio := max(fim-chatLinhas, 0)
+	for i, m := range jogo.Chat[inicio:fim] {
+		autor := fmt.Sprintf("#%d", m.PlayerID)
+		cor := CorVerde
+		switch m.PlayerID {
+		case 0:
+			autor = "servidor"
+			cor = CorMagenta
+		case myID:
+			autor = "você"
+			cor = CorCinzaEscuro
+		}
+		linha := fmt.Sprintf("%s: %s", autor, m.Text)
+		for j, c := range []rune(linha) {
+			termbox.SetCell(j, y0+i, c, cor, CorPadrao)
+		}
+	}
+
+	y := y0 + chatLinhas
+	if jogo.ChatRolagem > 0 {
+		aviso := fmt.Sprintf("(%d mensagens mais novas abaixo, PgDn para descer)", jogo.ChatRolagem)
+		for j, c := range aviso {
+			termbox.SetCell(j, y+1, c, CorTexto, CorPadrao)
+		}
+	}
+	if jogo.Digitando {
+		entrada := "> " + string(jogo.ChatTexto)
+		for j, c := range []rune(entrada) {
+			termbox.SetCell(j, y, c, CorAmarelo, CorPadrao)
+		}
+		termbox.SetCursor(len([]rune(entrada)), y)
+	} else {
+		termbox.HideCursor()
+	}
+}
+
+// Desenha o placar numa caixa no meio do mapa
+func interfaceDesenharPlacar(jogo *Jogo) {
+	linhas := []string{
+		"                         PLACAR",
+		"",
+		fmt.Sprintf("%-28s %7s %10s %8s", "Jogador", "Moedas", "Sobreviveu", "Portais"),
+	}
+	for _, e := range jogo.Placar {
+		nome := e.Username
+		if e.Username == myUsername {
+			nome += " (você)"
+		}
+		linhas = append(linhas, fmt.Sprintf("%-28s %7d %9ds %8d", nome, e.Coins, e.BestSurvivalSecs, e.Portals))
+	}
+	if len(jogo.Placar) == 0 {
+		linhas = append(linhas, "Ninguém pontuou ainda")
+	}
+	linhas = append(linhas, "", "TAB para fechar")
+
+	// Caixa com uma margem de um caractere
+	largura := 0
+	for _, l := range linhas {
+		largura = max(largura, len([]rune(l)))
+	}
+	largura += 4
+	altura := len(linhas) + 2
+	x0 := max((len(jogo.Mapa[0])-largura)/2, 0)
+	y0 := max((len(jogo.Mapa)-altura)/2, 0)
+
+	for y := range altura {
+		for x := range largura {
+			termbox.SetCell(x0+x, y0+y, ' ', CorPadrao, CorPadrao)
+		}
+	}
+	for i, l := range linhas {
+		for j, c := range []rune(l) {
+			termbox.SetCell(x0+2+j, y0+1+i, c, CorAmarelo, CorPadrao)
+		}
+	}
+}
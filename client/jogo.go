@@ -4,14 +4,16 @@ import (
 	"bufio"
 	"fmt"
 	"jogo/shared"
+	"maps"
 	"os"
+	"sync"
 )
 
 // goroutines que funcionam localmente
 var (
-	portalChannel   = make(chan bool)
 	mapChannel      = make(chan func(*Jogo))
 	gameOverChannel = make(chan struct{})
+	jogoMu          sync.Mutex // Protege o Jogo entre o loop principal, o mapManager e o renderManager
 )
 
 // Cria e retorna uma nova instância do jogo
@@ -32,10 +34,21 @@ func jogoCarregarMapa(nome string, jogo *Jogo) error {
 	}
 	defer arq.Close()
 
+	var linhas []string
 	scanner := bufio.NewScanner(arq)
-	y := 0
 	for scanner.Scan() {
-		linha := scanner.Text()
+		linhas = append(linhas, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	jogoMontarMapa(linhas, jogo)
+	return nil
+}
+
+// Constrói o mapa do jogo a partir das linhas (do arquivo ou enviadas pelo servidor)
+func jogoMontarMapa(linhas []string, jogo *Jogo) {
+	for y, linha := range linhas {
 		var linhaElems []Elemento
 		runes := []rune(linha)
 		for x, ch := range runes {
@@ -44,11 +57,11 @@ func jogoCarregarMapa(nome string, jogo *Jogo) error {
 			case Parede.simbolo:
 				e = Parede
 			case Inimigo.simbolo:
-				e = Inimigo
+				// Inimigos andam e são desenhados a partir do estado do servidor
 			case Vegetacao.simbolo:
 				e = Vegetacao
 			case Personagem.simbolo:
-				jogo.PosX, jogo.PosY = x, y // registra a posição inicial do personagem
+				// Só marca um ponto de nascimento; quem escolhe onde nascemos é o servidor
 			case Pato.simbolo:
 				jogo.PatoPosX, jogo.PatoPosY = x, y
 				jogo.PatoUltimoVisitado = Vazio
@@ -57,9 +70,7 @@ func jogoCarregarMapa(nome string, jogo *Jogo) error {
 			linhaElems = append(linhaElems, e)
 		}
 		jogo.Mapa = append(jogo.Mapa, linhaElems)
-		y++
 	}
-	return scanner.Err()
 }
 
 // Verifica se o personagem pode se mover para a posição (x, y)
@@ -71,50 +82,148 @@ func jogoPodeMoverPara(jogo *Jogo, x, y int) bool {
 		return false // Bateu em parede, pato, etc.
 	}
 
-	// Verifica se bateu em outro jogador
+	// Verifica se bateu em um inimigo
+	if jogoTemInimigo(jogo, x, y) {
+		return false
+	}
+
+	// Verifica se bateu em outro jogador (mortos não ocupam espaço)
 	for _, player := range jogo.Players {
-		if player.PosX == x && player.PosY == y {
+		if !player.Dead && player.PosX == x && player.PosY == y {
 			return false
 		}
 	}
 	return true
 }
 
+// Verifica se há um inimigo em (x, y)
+func jogoTemInimigo(jogo *Jogo, x, y int) bool {
+	for _, inimigo := range jogo.Inimigos {
+		if inimigo.X == x && inimigo.Y == y {
+			return true
+		}
+	}
+	return false
+}
+
 // Move um elemento para a nova posição
-func jogoMoverElemento(jogo *Jogo, x, y, dx, dy int) bool {
+func jogoMoverElemento(jogo *Jogo, x, y, dx, dy int) {
 	nx, ny := x+dx, y+dy
 	// Obtem elemento atual na posição
 	elemento := jogo.Mapa[y][x]
 	elementoNaNovaPosicao := jogo.Mapa[ny][nx]
 
 	switch elementoNaNovaPosicao.simbolo {
-	case Moeda.simbolo:
+	case Moeda.simbolo, PortalAtivo.simbolo:
+		// Moeda e portal são consumidos; o servidor decide o efeito
 		jogo.Mapa[y][x] = jogo.UltimoVisitado // restaura o conteúdo anterior
 		jogo.UltimoVisitado = Vazio
 		jogo.Mapa[ny][nx] = elemento // move o elemento
-		select {
-		case portalChannel <- true:
-		default:
-		}
-		return false
-
-	case PortalAtivo.simbolo:
-		// Encontra uma nova posição aleatória para o teletransporte
-		newX, newY := teleportarJogador(jogo)
-		jogo.PatoInteragiu = true
-		jogo.StatusMsg = fmt.Sprintf("Teletransportado para (%d, %d)!", newX, newY)
-		jogo.Mapa[y][x] = jogo.UltimoVisitado       // restaura o conteúdo anterior
-		jogo.Mapa[ny][nx] = Vazio                   // Remove o portal (consumido)
-		jogo.UltimoVisitado = jogo.Mapa[newY][newX] // guarda o conteúdo atual
-		jogo.Mapa[newY][newX] = elemento            // move o elemento
-		jogo.PosX, jogo.PosY = newX, newY           // Atualiza a posição
-		return true                                 // indica que houve teletransporte
 
 	default:
 		jogo.Mapa[y][x] = jogo.UltimoVisitado   // restaura o conteúdo anterior
 		jogo.UltimoVisitado = jogo.Mapa[ny][nx] // guarda o conteúdo atual
 		jogo.Mapa[ny][nx] = elemento            // move o elemento
-		return false                            // movimento normal
+	}
+}
+
+// Coloca o personagem em (x, y) num mapa recém-montado, onde ele ainda
+// não ocupa nenhuma célula e não há nada a restaurar na posição anterior
+func jogoPosicionar(jogo *Jogo, x, y int) {
+	jogo.PosX, jogo.PosY = x, y
+	jogo.UltimoVisitado = Vazio
+	if dentroDoMapa(jogo, x, y) {
+		jogo.UltimoVisitado = jogo.Mapa[y][x] // guarda o conteúdo atual
+		jogo.Mapa[y][x] = Vazio               // a célula do personagem fica vazia
+	}
+}
+
+// Leva o personagem para a posição (x, y) informada pelo servidor
+func jogoCorrigirPosicao(jogo *Jogo, x, y int) {
+	if jogo.PosX == x && jogo.PosY == y {
+		return
+	}
+	if !dentroDoMapa(jogo, jogo.PosX, jogo.PosY) {
+		jogoPosicionar(jogo, x, y)
+		return
+	}
+	elemento := jogo.Mapa[jogo.PosY][jogo.PosX]
+	jogo.Mapa[jogo.PosY][jogo.PosX] = jogo.UltimoVisitado // restaura o conteúdo anterior
+	jogo.UltimoVisitado = jogo.Mapa[y][x]                 // guarda o conteúdo atual
+	jogo.Mapa[y][x] = elemento                            // move o elemento
+	jogo.PosX, jogo.PosY = x, y
+}
+
+// Troca o mapa local pelo recarregado no servidor, mantendo o personagem onde está.
+// Se o mapa encolheu e ele ficou de fora, espera o servidor levá-lo para dentro.
+func jogoTrocarMapa(jogo *Jogo, linhas []string) {
+	jogo.Mapa = nil
+	jogoMontarMapa(linhas, jogo)
+	// O mapa novo não tem moeda nem portal desenhados
+	jogo.Moeda = shared.CoinState{}
+	jogo.Portal = shared.PortalState{}
+	jogo.PortalAtivo = false
+	jogoPosicionar(jogo, jogo.PosX, jogo.PosY)
+	jogo.StatusMsg = "O mapa foi recarregado pelo servidor"
+}
+
+// Aplica no estado local as mudanças de jogadores vindas do servidor
+func jogoAplicarDelta(jogo *Jogo, delta *shared.StateDelta) {
+	// O mapa vem antes: moeda, portal e pato são desenhados sobre ele
+	if delta.MapVersion != jogo.MapaVersao && len(delta.MapLines) > 0 {
+		jogoTrocarMapa(jogo, delta.MapLines)
+		jogo.MapaVersao = delta.MapVersion
+	}
+	jogoAtualizarMoeda(jogo, delta.Coin)
+	jogoAtualizarPortal(jogo, delta.Portal)
+	jogoAtualizarPato(jogo, delta.Duck)
+	jogo.Inimigos = delta.Enemies
+	jogoAtualizarChat(jogo, delta.ChatSeq)
+
+	antes, tinha := jogo.Players[myID]
+	defer func() {
+		depois, tem := jogo.Players[myID]
+		switch {
+		case tinha && !tem:
+			jogo.StatusMsg = "Você foi removido do servidor."
+		case tinha:
+			jogoAtualizarVida(jogo, antes, depois)
+			// O servidor nos moveu (administrador ou mapa recarregado)
+			if depois.Warps != antes.Warps {
+				jogoCorrigirPosicao(jogo, depois.PosX, depois.PosY)
+				jogo.StatusMsg = fmt.Sprintf("O servidor te levou para (%d, %d)", depois.PosX, depois.PosY)
+			}
+		}
+	}()
+
+	if delta.Full {
+		jogo.Players = delta.AllPlayers
+		return
+	}
+	// Monta um mapa novo, o renderManager pode estar lendo o atual
+	players := maps.Clone(jogo.Players)
+	if players == nil {
+		players = make(map[int]shared.PlayerState)
+	}
+	maps.Copy(players, delta.Joined)
+	maps.Copy(players, delta.Moved)
+	for _, id := range delta.Left {
+		delete(players, id)
+	}
+	jogo.Players = players
+}
+
+// Reage às mudanças de vida do jogador local vindas do servidor
+func jogoAtualizarVida(jogo *Jogo, antes, depois shared.PlayerState) {
+	switch {
+	case depois.Dead && !antes.Dead:
+		jogo.StatusMsg = "Você morreu! Renascendo em instantes..."
+	case antes.Dead && !depois.Dead:
+		// Renascemos em outro lugar
+		jogoCorrigirPosicao(jogo, depois.PosX, depois.PosY)
+		jogo.StatusMsg = fmt.Sprintf("Você renasceu em (%d, %d)!", depois.PosX, depois.PosY)
+	case depois.HP < antes.HP:
+		jogo.StatusMsg = fmt.Sprintf("Um inimigo te acertou! Vida: %d", depois.HP)
 	}
 }
 
@@ -123,7 +232,9 @@ func mapManager(jogo *Jogo) {
 	for {
 		select {
 		case cmd := <-mapChannel:
+			jogoMu.Lock()
 			cmd(jogo)
+			jogoMu.Unlock()
 		case <-gameOverChannel:
 			return
 		}
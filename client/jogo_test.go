@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+// Um mapa sem ☺ não deixa o personagem em (0, 0) arrastando a parede do canto
+func TestPosicionarSemPersonagemNoMapa(t *testing.T) {
+	jogo := jogoNovo()
+	jogoMontarMapa([]string{"▤▤▤▤▤", "▤ ♣ ▤", "▤▤▤▤▤"}, &jogo)
+	jogoPosicionar(&jogo, 2, 1)
+
+	if jogo.PosX != 2 || jogo.PosY != 1 {
+		t.Fatalf("posição = (%d, %d), esperava (2, 1)", jogo.PosX, jogo.PosY)
+	}
+	if jogo.UltimoVisitado != Vegetacao {
+		t.Errorf("último visitado = %q, esperava a vegetação de baixo do personagem", jogo.UltimoVisitado.simbolo)
+	}
+
+	jogoCorrigirPosicao(&jogo, 3, 1)
+	if jogo.Mapa[0][0] != Parede {
+		t.Errorf("canto = %q, esperava a parede no lugar", jogo.Mapa[0][0].simbolo)
+	}
+	if jogo.Mapa[1][2] != Vegetacao {
+		t.Errorf("célula deixada = %q, esperava a vegetação de volta", jogo.Mapa[1][2].simbolo)
+	}
+	if jogo.Mapa[1][3] != Vazio {
+		t.Errorf("célula do personagem = %q, esperava vazia", jogo.Mapa[1][3].simbolo)
+	}
+}
+
+// Um mapa recarregado menor deixa o personagem de fora sem quebrar nada
+// até o servidor levá-lo para dentro
+func TestTrocarMapaMenor(t *testing.T) {
+	jogo := jogoNovo()
+	jogoMontarMapa([]string{"▤▤▤▤▤▤▤", "▤     ▤", "▤▤▤▤▤▤▤"}, &jogo)
+	jogoPosicionar(&jogo, 5, 1)
+
+	jogoTrocarMapa(&jogo, []string{"▤▤▤▤", "▤  ▤", "▤▤▤▤"})
+	if jogo.Mapa[0][0] != Parede {
+		t.Errorf("canto = %q, esperava a parede no lugar", jogo.Mapa[0][0].simbolo)
+	}
+	personagemMover('a', &jogo) // Não pode indexar a posição de fora
+	if jogo.PosX != 5 || jogo.PosY != 1 {
+		t.Errorf("andou para (%d, %d) fora do mapa", jogo.PosX, jogo.PosY)
+	}
+
+	jogoCorrigirPosicao(&jogo, 1, 1)
+	if jogo.PosX != 1 || jogo.PosY != 1 || jogo.Mapa[1][1] != Vazio || jogo.Mapa[0][0] != Parede {
+		t.Errorf("depois da correção: posição (%d, %d), mapa %v", jogo.PosX, jogo.PosY, jogo.Mapa)
+	}
+	personagemMover('d', &jogo)
+	if jogo.PosX != 2 {
+		t.Errorf("x = %d depois de andar para a direita, esperava 2", jogo.PosX)
+	}
+}
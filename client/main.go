@@ -1,6 +1,8 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
 	"net/rpc"
 	"os"
@@ -11,14 +13,16 @@ import (
 )
 
 var (
-	renderChannel   = make(chan struct{})              // Sinaliza para renderManager redesenhar
-	client          *rpc.Client                        // Conexão RPC com o servidor
-	myID            int                                // Nosso ID de jogador
-	jogo            Jogo                               // O estado de jogo local é global
-	lastServerState = make(map[int]shared.PlayerState) // Último estado vindo do server
-	rpcMu           sync.Mutex                         // Protege chamadas RPC
-	sequenceNumber  = 0                                // Contador de comandos
-	seqMu           sync.Mutex                         // Protege sequenceNumber garantindo execução atômica
+	renderChannel   = make(chan struct{})                    // Sinaliza para renderManager redesenhar
+	updateChannel   = make(chan *shared.UpdateStateArgs, 32) // Movimentos a enviar, na ordem em que ocorreram
+	client          *rpc.Client                              // Conexão RPC com o servidor, trocada por reconectar
+	myID            int                                      // Nosso ID de jogador
+	myUsername      string                                   // Conta com que entramos
+	jogo            Jogo                                     // O estado de jogo local é global
+	lastServerState = make(map[int]shared.PlayerState)       // Último estado vindo do server
+	rpcMu           sync.Mutex                               // Protege chamadas RPC
+	sequenceNumber  = 0                                      // Contador de comandos
+	seqMu           sync.Mutex                               // Protege sequenceNumber garantindo execução atômica
 )
 
 // garante que cada chamada RPC que modifica estado
@@ -30,49 +34,98 @@ func getNovoSequenceNumber() int {
 	return sequenceNumber
 }
 
-// função genérica para chamadas RPC com reenvio
-func callWithRetry(serviceMethod string, args interface{}, reply interface{}) {
+// função genérica para chamadas RPC com reenvio.
+// Só reenvia quando a falha é de transporte: um erro do servidor (sessão
+// expirada, mensagem vazia...) teria a mesma resposta na próxima tentativa.
+// Retorna o último erro se todas as tentativas falharem; reply fica incompleto.
+func callWithRetry(serviceMethod string, args interface{}, reply interface{}) error {
+	return chamarRPC(serviceMethod, args, reply, 3)
+}
+
+// chamarRPC faz a chamada com até tentativas envios
+func chamarRPC(serviceMethod string, args interface{}, reply interface{}, tentativas int) error {
 	// Trava o RPC para não enviar dois comandos ao mesmo tempo
 	rpcMu.Lock()
 	defer rpcMu.Unlock()
 
-	// Tenta até 3 vezes
-	const maxRetries = 3
-	for i := range maxRetries {
+	var err error
+	for i := range tentativas {
 
 		// Se em alguma tentativa retornar com erro nil, retorna sucesso
-		err := client.Call(serviceMethod, args, reply)
+		c := rpcClient()
+		err = c.Call(serviceMethod, args, reply)
 		if err == nil {
-			return
+			return nil
 		}
 
 		// loga o erro
-		log.Printf("Erro RPC (%s): %v. Tentativa %d/%d", serviceMethod, err, i+1, maxRetries)
+		log.Printf("Erro RPC (%s): %v. Tentativa %d/%d", serviceMethod, err, i+1, tentativas)
+
+		if !erroDeTransporte(err) {
+			return err
+		}
+		// Se a conexão caiu, tenta voltar como o mesmo jogador
+		if conexaoPerdida(err) {
+			reconectar(c)
+		}
 
-		time.Sleep(500 * time.Millisecond) // Espera antes de tentar de novo
+		if i+1 < tentativas {
+			time.Sleep(500 * time.Millisecond) // Espera antes de tentar de novo
+		}
 	}
-	log.Printf("Falha ao enviar RPC (%s) após %d tentativas.", serviceMethod, maxRetries)
+	if tentativas > 1 {
+		log.Printf("Falha ao enviar RPC (%s) após %d tentativas.", serviceMethod, tentativas)
+	}
+	return err
 }
 
 func main() {
+	sala := flag.String("sala", "", "sala do servidor em que entrar (vazio usa a padrão)")
+	usuarioFlag := flag.String("usuario", "", "nome da conta (pergunta se vazio)")
+	registrar := flag.Bool("registrar", false, "cria a conta antes de entrar")
+	usarTLS := flag.Bool("tls", false, "conecta ao servidor por TLS")
+	ca := flag.String("ca", "", "certificado PEM da CA que assinou o servidor (implica -tls)")
+	pin := flag.String("pin", "", "SHA-256 em base64 da chave do servidor, mostrado pelo gerarcert (implica -tls)")
+	flag.Parse()
+
 	var err error
+	tlsConfig, err = configurarTLS(*usarTLS, *ca, *pin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Configuração de TLS inválida: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Conecta ao servidor RPC
-	client, err = rpc.Dial("tcp", "localhost:12345")
+	client, err = discar()
 	if err != nil {
-		log.Fatal("Erro ao conectar:", err)
+		fmt.Fprintf(os.Stderr, "Não foi possível falar com o servidor em %s. Ele está rodando?\n(%v)\n", serverAddr, err)
+		os.Exit(1)
+	}
+
+	usuario, senha := lerCredenciais(*usuarioFlag)
+	if *registrar {
+		err = client.Call("GameService.Register", &shared.RegisterArgs{Username: usuario, Password: senha}, &shared.RegisterReply{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Não foi possível criar a conta: %v\n", err)
+			os.Exit(1)
+		}
+		log.Printf("Conta %s criada", usuario)
 	}
 
 	// Chama o Connect para entrar no jogo
-	connectArgs := &shared.ConnectArgs{}
+	connectArgs := &shared.ConnectArgs{Username: usuario, Password: senha, Room: *sala, Protocol: shared.ProtocolVersion}
 	connectReply := &shared.ConnectReply{}
 	err = client.Call("GameService.Connect", connectArgs, connectReply)
 	if err != nil {
-		log.Fatal("Erro ao conectar:", err)
+		fmt.Fprintln(os.Stderr, explicarRecusa(err))
+		os.Exit(1)
 	}
 	// Servidor retornou nosso ID e a lista de jogadores
 	myID = connectReply.PlayerID
+	myUsername = usuario
+	sessionToken = connectReply.SessionToken
 	lastServerState = connectReply.AllPlayers
-	log.Printf("Conectado. ID: %d", myID)
+	log.Printf("Conectado. ID: %d, sala: %s", myID, connectReply.Room)
 
 	// Inicializa a interface (termbox)
 	interfaceIniciar()
@@ -81,44 +134,55 @@ func main() {
 
 	// Usa "mapa.txt" como arquivo padrão ou lê o primeiro argumento
 	mapaFile := "mapa.txt"
-	if len(os.Args) > 1 {
-		mapaFile = os.Args[1]
+	if flag.NArg() > 0 {
+		mapaFile = flag.Arg(0)
 	}
 
-	// Inicializa o jogo
+	// Inicializa o jogo com o mapa da sala; o arquivo local só é usado
+	// se o servidor não mandar nenhum
 	jogo = jogoNovo() // 'jogo' é global
-	if err := jogoCarregarMapa(mapaFile, &jogo); err != nil {
+	if len(connectReply.MapLines) > 0 {
+		jogoMontarMapa(connectReply.MapLines, &jogo)
+	} else if err := jogoCarregarMapa(mapaFile, &jogo); err != nil {
 		panic(err)
 	}
+	// Nasce onde o servidor mandou, não no personagem do mapa
+	jogoPosicionar(&jogo, connectReply.PosX, connectReply.PosY)
+	jogo.MapaVersao = connectReply.MapVersion
 	jogo.Players = lastServerState // Seta estado inicial dos players
 
-	// goroutine para atualizar o servidor quando mudarmos de estado
-	go updateServerMyState()
+	// goroutine que envia nossos movimentos ao servidor, em ordem
+	go updateManager()
+	go heartbeatManager()
 
 	// 7. Inicia todos os managers LOCAIS (como no original)
 	go mapManager(&jogo)
-	go coinManager(&jogo)
-	go portalManager(&jogo)
-	go patoManager(&jogo)
+	go stateManager()
 	go renderManager(&jogo)
 
 	// 8. Desenha o estado inicial
+	jogoMu.Lock()
 	interfaceDesenharJogo(&jogo)
+	jogoMu.Unlock()
 
 	// 9. Loop principal de entrada
 	for {
-		evento := interfaceLerEventoTeclado()
+		evento := interfaceLerEventoTeclado(jogo.Digitando)
 
+		// O mapManager pode trocar o mapa a qualquer momento; a ação roda com o jogo travado
+		jogoMu.Lock()
 		// Guarda Posição antiga para checar se houve mudança
 		oldX, oldY := jogo.PosX, jogo.PosY
 
-		if continuar := personagemExecutarAcao(evento, &jogo); !continuar {
-			break
-		}
+		continuar := personagemExecutarAcao(evento, &jogo)
 
 		// Se a Posição mudou, avisa o servidor
-		if oldX != jogo.PosX || oldY != jogo.PosY {
-			go updateServerMyState()
+		if continuar && (oldX != jogo.PosX || oldY != jogo.PosY) {
+			enviarMovimento(jogo.PosX, jogo.PosY)
+		}
+		jogoMu.Unlock()
+		if !continuar {
+			break
 		}
 
 		select {
@@ -128,67 +192,147 @@ func main() {
 	}
 }
 
-// atualiza o estado do jogador no servidor
-func updateServerMyState() {
-	// Prepara argumentos
-	args := &shared.UpdateStateArgs{
-		PlayerID:       myID,
-		NewX:           jogo.PosX,
-		NewY:           jogo.PosY,
+// enfileira a posição (x, y) do jogador para envio ao servidor.
+// A posição e o sequence number são capturados aqui para manter a ordem.
+func enviarMovimento(x, y int) {
+	updateChannel <- &shared.UpdateStateArgs{
+		SessionToken:   sessionToken,
+		NewX:           x,
+		NewY:           y,
 		SequenceNumber: getNovoSequenceNumber(),
 	}
+}
+
+// updateManager envia os movimentos ao servidor um por vez
+func updateManager() {
+	for {
+		select {
+		case args := <-updateChannel:
+			updateServerMyState(args)
+		case <-gameOverChannel:
+			return
+		}
+	}
+}
+
+// atualiza o estado do jogador no servidor
+func updateServerMyState(args *shared.UpdateStateArgs) {
 	reply := &shared.UpdateStateReply{}
 
-	// Usa nossa nova função com reenvio
-	callWithRetry("GameService.UpdateState", args, reply)
+	// Usa nossa nova função com reenvio. Sem resposta não há posição
+	// autoritativa para corrigir; o próximo delta do servidor acerta a nossa.
+	if err := callWithRetry("GameService.UpdateState", args, reply); err != nil {
+		return
+	}
+
+	if reply.Reason == shared.MoveUnknown {
+		return
+	}
+
+	// Servidor recusou o movimento ou nos teletransportou: vai para a posição autoritativa
+	if reply.PosX != args.NewX || reply.PosY != args.NewY {
+		x, y := reply.PosX, reply.PosY
+		msg := fmt.Sprintf("Teletransportado para (%d, %d)!", x, y)
+		if !reply.Accepted {
+			msg = fmt.Sprintf("Movimento recusado pelo servidor: %s", reply.Reason)
+		}
+		mapChannel <- func(j *Jogo) {
+			jogoCorrigirPosicao(j, x, y)
+			j.StatusMsg = msg
+		}
+		select {
+		case renderChannel <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// heartbeatManager avisa periodicamente o servidor que continuamos vivos
+func heartbeatManager() {
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			args := &shared.HeartbeatArgs{SessionToken: sessionToken}
+			reply := &shared.HeartbeatReply{}
+			callWithRetry("GameService.Heartbeat", args, reply)
+		case <-gameOverChannel:
+			return
+		}
+	}
 }
 
 // notifica o servidor que estamos saindo do jogo
 func notifyDisconnect() {
 	log.Println("Notificando servidor da desconexão...")
 	args := &shared.DisconnectArgs{
-		PlayerID:       myID,
+		SessionToken:   sessionToken,
 		SequenceNumber: getNovoSequenceNumber(),
 	}
 	reply := &shared.DisconnectReply{}
 
 	// Usa nossa nova função com reenvio
 	callWithRetry("GameService.Disconnect", args, reply)
-	client.Close()
+	rpcClient().Close()
+}
+
+// stateManager espera o servidor avisar que o estado dos jogadores mudou
+func stateManager() {
+	var version int64
+	for {
+		select {
+		case <-gameOverChannel:
+			return
+		default:
+		}
+
+		args := &shared.WaitForStateArgs{SessionToken: sessionToken, SinceVersion: version}
+		reply := &shared.WaitForStateReply{}
+
+		// Não usa rpcMu: o long-poll pode demorar e não deve travar nossos UpdateState
+		c := rpcClient()
+		if err := c.Call("GameService.WaitForState", args, reply); err != nil {
+			log.Printf("Erro RPC (GameService.WaitForState): %v", err)
+			if conexaoPerdida(err) {
+				reconectar(c)
+			}
+			time.Sleep(500 * time.Millisecond)
+			continue
+		}
+
+		// Expirou sem mudanças
+		if reply.Version == version {
+			continue
+		}
+		version = reply.Version
+
+		// Aplica as mudanças no estado local dos players
+		mapChannel <- func(j *Jogo) {
+			jogoAplicarDelta(j, &reply.StateDelta)
+		}
+		select {
+		case renderChannel <- struct{}{}:
+		default:
+		}
+	}
 }
 
 // renderManager
 func renderManager(jogo *Jogo) {
-	// Timer para render automático a cada 100ms
+	// Timer para redesenhar as mudanças locais (moedas, portal, pato)
 	renderTicker := time.NewTicker(100 * time.Millisecond)
 	defer renderTicker.Stop()
 
 	for {
 		select {
 		case <-renderTicker.C:
-			// A cada tick, busca estado do servidor
-			args := &shared.GetStateArgs{}
-			reply := &shared.GetStateReply{}
-
-			// Usamos o mutex para proteger a chamada RPC
-			rpcMu.Lock()
-			err := client.Call("GameService.GetState", args, reply)
-			rpcMu.Unlock()
-
-			// Se a chamada foi bem sucedida]
-			if err == nil {
-				// Atualiza o estado local de todos os players
-				mapChannel <- func(j *Jogo) {
-					j.Players = reply.AllPlayers
-				}
-			}
-
-			// Desenha com o estado atualizado
-			interfaceDesenharJogo(jogo)
+			desenharTravado(jogo)
 
-		// render para quando nos movemos
+		// render para quando nos movemos ou o servidor mandou novidades
 		case <-renderChannel:
-			interfaceDesenharJogo(jogo)
+			desenharTravado(jogo)
 
 		// finaliza quando o jogo acabar
 		case <-gameOverChannel:
@@ -196,3 +340,10 @@ func renderManager(jogo *Jogo) {
 		}
 	}
 }
+
+// desenharTravado desenha o jogo sem deixar o mapManager mexer nele no meio
+func desenharTravado(jogo *Jogo) {
+	jogoMu.Lock()
+	defer jogoMu.Unlock()
+	interfaceDesenharJogo(jogo)
+}
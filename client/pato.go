@@ -2,80 +2,43 @@ package main
 
 import (
 	"fmt"
-	"math"
-	"time"
-)
 
-func patoManager(jogo *Jogo) {
-	moveTicker := time.NewTicker(1 * time.Second)
-	defer moveTicker.Stop()
+	"jogo/shared"
+)
 
-	for {
-		select {
-		case <-moveTicker.C:
-			tentarMoverPato(jogo)
-		case <-gameOverChannel:
-			return
-		}
+// Atualiza o pato do mapa local com o estado vindo do servidor
+func jogoAtualizarPato(jogo *Jogo, pato shared.DuckState) {
+	if !pato.Present {
+		return
 	}
-}
-
-func tentarMoverPato(jogo *Jogo) {
-	cmd := func(jogo *Jogo) {
 
-		portalAtivo := jogo.PortalAtivo
-		patoInteragiu := jogo.PatoInteragiu
-
-		// Se portal não está ativo ou pato foi interagido, não move
-		if !portalAtivo {
-			return
+	// Move o pato para a posição do servidor
+	if pato.X != jogo.PatoPosX || pato.Y != jogo.PatoPosY {
+		if dentroDoMapa(jogo, jogo.PatoPosX, jogo.PatoPosY) && jogo.Mapa[jogo.PatoPosY][jogo.PatoPosX].simbolo == Pato.simbolo {
+			jogo.Mapa[jogo.PatoPosY][jogo.PatoPosX] = jogo.PatoUltimoVisitado
 		}
-
-		if patoInteragiu {
-			return
+		if dentroDoMapa(jogo, pato.X, pato.Y) {
+			jogo.PatoUltimoVisitado = jogo.Mapa[pato.Y][pato.X]
+			jogo.Mapa[pato.Y][pato.X] = Pato
 		}
-
-		moverPato(jogo)
+		jogo.PatoPosX, jogo.PatoPosY = pato.X, pato.Y
+		jogo.StatusMsg = fmt.Sprintf("Pato está em (%d, %d)", pato.X, pato.Y)
 	}
 
-	mapChannel <- cmd
-}
-
-func moverPato(jogo *Jogo) {
-
-	patoPosX, patoPosY := jogo.PatoPosX, jogo.PatoPosY
-	jogo.StatusMsg = fmt.Sprintf("Pato está em (%d, %d)", patoPosX, patoPosY)
-	novoY := patoPosY - 1
-
-	// Verifica se a nova posição é válida
-	if novoY >= 0 && novoY < len(jogo.Mapa) && novoY >= 0 && novoY < len(jogo.Mapa[novoY]) {
-		if !jogo.Mapa[novoY][patoPosX].tangivel {
-			// Remove o pato da posição atual
-			jogo.Mapa[jogo.PatoPosY][jogo.PatoPosX] = jogo.PatoUltimoVisitado
-
-			jogo.PatoUltimoVisitado = jogo.Mapa[novoY][patoPosX]
-			// Coloca o pato na nova posição
-			jogo.Mapa[novoY][patoPosX] = Pato
-			// Atualiza a posição do pato no estado
-			jogo.PatoPosY = novoY
+	// Avisa quando alguém faz carinho
+	if pato.Stopped && !jogo.PatoInteragiu && pato.PettedBy != 0 {
+		if pato.PettedBy == myID {
+			jogo.StatusMsg = "Você fez carinho no pato! Ele parou de se mover."
+		} else {
+			jogo.StatusMsg = fmt.Sprintf("Jogador %d fez carinho no pato! Ele parou de se mover.", pato.PettedBy)
 		}
 	}
+	jogo.PatoInteragiu = pato.Stopped
 }
 
-func interagirComPato(jogo *Jogo) {
-	// --- CORREÇÃO ---
-	// A função agora checa o 'jogo.PosX/Y' (jogador local)
-	// em vez de um 'playerID'
-	distanciaX := math.Abs(float64(jogo.PosX - jogo.PatoPosX))
-	distanciaY := math.Abs(float64(jogo.PosY - jogo.PatoPosY))
-
-	if (distanciaX <= 1 && distanciaY == 0) || (distanciaY <= 1 && distanciaX == 0) {
-		cmd := func(j *Jogo) {
-			j.PatoInteragiu = true
-			// --- CORREÇÃO ---
-			// Atualiza o StatusMsg do jogo, não de um player específico
-			j.StatusMsg = "Você fez carinho no pato! Ele parou de se mover."
-		}
-		mapChannel <- cmd
-	}
+// Pede ao servidor para fazer carinho no pato; ele confere se estamos ao lado
+func interagirComPato() {
+	args := &shared.InteractArgs{SessionToken: sessionToken}
+	reply := &shared.InteractReply{}
+	callWithRetry("GameService.Interact", args, reply)
 }
@@ -18,23 +18,28 @@ func personagemMover(tecla rune, jogo *Jogo) {
 		dx = 1 // Move para a direita
 	}
 
+	// Mortos não andam, nem quem ficou fora de um mapa recarregado menor
+	if jogo.Players[myID].Dead || !dentroDoMapa(jogo, jogo.PosX, jogo.PosY) {
+		return
+	}
+
 	nx, ny := jogo.PosX+dx, jogo.PosY+dy
+	// Trombar num inimigo não nos move, mas o servidor precisa saber para aplicar o dano
+	if (dx != 0 || dy != 0) && jogoTemInimigo(jogo, nx, ny) {
+		enviarMovimento(nx, ny)
+		return
+	}
 	// Verifica se o movimento é permitido e realiza a movimentação
 	if jogoPodeMoverPara(jogo, nx, ny) {
-		// jogoMoverElemento retorna true se houve teletransporte
-		teletransportou := jogoMoverElemento(jogo, jogo.PosX, jogo.PosY, dx, dy)
-
-		// Se não houve teletransporte, atualiza normalmente a posição
-		if !teletransportou {
-			jogo.PosX, jogo.PosY = nx, ny
-		}
-		// Se houve teletransporte, a Posição já foi atualizada em jogoMoverElemento
+		jogoMoverElemento(jogo, jogo.PosX, jogo.PosY, dx, dy)
+		// Se pisamos num portal, o servidor responde com o destino do teletransporte
+		jogo.PosX, jogo.PosY = nx, ny
 	}
 }
 
 // Define o que ocorre quando o jogador pressiona a tecla de interação
 func personagemInteragir(jogo *Jogo) {
-	interagirComPato(jogo)
+	go interagirComPato() // O resultado chega para todos com o estado do pato
 	jogo.StatusMsg = fmt.Sprintf("Interagindo em (%d, %d)", jogo.PosX, jogo.PosY)
 }
 
@@ -48,6 +53,12 @@ func personagemExecutarAcao(ev EventoTeclado, jogo *Jogo) bool {
 	case "interagir":
 		// Executa a ação de interação
 		personagemInteragir(jogo)
+	case "placar":
+		// Abre ou fecha o placar
+		alternarPlacar(jogo)
+	case "chat", "chat-letra", "chat-apagar", "chat-enviar", "chat-cancelar", "chat-subir", "chat-descer":
+		// Digitação e rolagem do chat
+		chatExecutarAcao(ev, jogo)
 	case "mover":
 		// Move o personagem com base na tecla
 		personagemMover(ev.Tecla, jogo)
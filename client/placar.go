@@ -0,0 +1,30 @@
+package main
+
+import (
+	"jogo/shared"
+)
+
+// Abre ou fecha o placar; ao abrir, busca a versão mais recente no servidor
+func alternarPlacar(jogo *Jogo) {
+	jogo.MostrarPlacar = !jogo.MostrarPlacar
+	if jogo.MostrarPlacar {
+		go buscarPlacar()
+	}
+}
+
+// Busca o placar no servidor e o entrega ao mapManager
+func buscarPlacar() {
+	args := &shared.GetLeaderboardArgs{SessionToken: sessionToken, OrderBy: shared.LeaderboardByCoins}
+	reply := &shared.GetLeaderboardReply{}
+	if callWithRetry("GameService.GetLeaderboard", args, reply) != nil {
+		return // Mantém o placar que já tínhamos
+	}
+
+	mapChannel <- func(j *Jogo) {
+		j.Placar = reply.Entries
+	}
+	select {
+	case renderChannel <- struct{}{}:
+	default:
+	}
+}
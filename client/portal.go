@@ -1,83 +1,28 @@
 package main
 
 import (
-	"math/rand"
-	"time"
+	"jogo/shared"
 )
 
-func portalManager(jogo *Jogo) {
-	var posicaoPortalX, posicaoPortalY int
+// Atualiza o portal do mapa local com o estado vindo do servidor
+func jogoAtualizarPortal(jogo *Jogo, portal shared.PortalState) {
+	antigo := jogo.Portal
 
-	for {
-		select {
-		case ativar := <-portalChannel:
-			if ativar {
-				cmd := func(jogo *Jogo) {
-					if !jogo.PortalAtivo {
-						var x, y int
-						jogo.PortalAtivo, x, y = ativarPortal(jogo)
-						posicaoPortalX, posicaoPortalY = x, y
-
-						go func() {
-							time.Sleep(15 * time.Second)
-							portalChannel <- false
-						}()
-					}
-				}
-				mapChannel <- cmd
-			} else {
-				cmd := func(jogo *Jogo) {
-					if jogo.PortalAtivo {
-						clearPortal(jogo, posicaoPortalX, posicaoPortalY)
-						jogo.PortalAtivo = false
-					}
-				}
-				mapChannel <- cmd
-			}
-		case <-gameOverChannel:
-			return
-		}
+	if antigo.Active && (!portal.Active || antigo.X != portal.X || antigo.Y != portal.Y) {
+		clearPortal(jogo, antigo.X, antigo.Y)
 	}
-}
-
-func ativarPortal(jogo *Jogo) (bool, int, int) {
-
-	maxY := len(jogo.Mapa)
-	maxX := len(jogo.Mapa[0])
-
-	for {
-		// Gera uma posição aleatória para x e y
-		x := rand.Intn(maxX)
-		y := rand.Intn(maxY)
-
-		// Verifica se a posição é válida (vazio e não tangível)
-		if !jogo.Mapa[y][x].tangivel && jogo.Mapa[y][x].simbolo == Vazio.simbolo {
 
-			jogo.Mapa[y][x] = PortalAtivo
-			jogo.PatoInteragiu = false
-			return true, x, y
-		}
+	if portal.Active && dentroDoMapa(jogo, portal.X, portal.Y) && jogo.Mapa[portal.Y][portal.X].simbolo == Vazio.simbolo {
+		jogo.Mapa[portal.Y][portal.X] = PortalAtivo
 	}
+
+	jogo.PortalAtivo = portal.Active
+	jogo.Portal = portal
 }
 
 func clearPortal(jogo *Jogo, x int, y int) {
 	// Verifica se ainda há um portal na posição
-	if jogo.Mapa[y][x].simbolo == PortalAtivo.simbolo {
+	if dentroDoMapa(jogo, x, y) && jogo.Mapa[y][x].simbolo == PortalAtivo.simbolo {
 		jogo.Mapa[y][x] = PortalInativo
-		jogo.PatoInteragiu = true
-	}
-}
-
-func teleportarJogador(jogo *Jogo) (int, int) {
-	maxY := len(jogo.Mapa)
-	maxX := len(jogo.Mapa[0])
-
-	for {
-		x := rand.Intn(maxX)
-		y := rand.Intn(maxY)
-
-		if !jogo.Mapa[y][x].tangivel && jogo.Mapa[y][x].simbolo == Vazio.simbolo {
-			return x, y
-		}
 	}
 }
@@ -13,6 +13,7 @@ type Elemento struct {
 var (
 	Personagem       = Elemento{'☺', CorCinzaEscuro, CorPadrao, true}
 	PersonagemRemoto = Elemento{'☻', CorVerde, CorPadrao, true} // Outros jogadores
+	PersonagemMorto  = Elemento{'✝', CorVermelho, CorPadrao, false}
 	Inimigo          = Elemento{'☠', CorVermelho, CorPadrao, true}
 	Parede           = Elemento{'▤', CorParede, CorFundoParede, true}
 	Vegetacao        = Elemento{'♣', CorVerde, CorPadrao, false}
@@ -26,6 +27,7 @@ var (
 // Jogo
 type Jogo struct {
 	Mapa               [][]Elemento // grade 2D representando o mapa
+	MapaVersao         int          // versão do mapa no servidor, muda quando ele é recarregado
 	PosX, PosY         int          // posição atual do personagem local
 	UltimoVisitado     Elemento     // elemento que estava na posição do personagem antes de mover
 	StatusMsg          string       // mensagem para a barra de status
@@ -33,6 +35,16 @@ type Jogo struct {
 	PatoInteragiu      bool         // se o pato foi interagido
 	PatoUltimoVisitado Elemento
 	PortalAtivo        bool
+	Portal             shared.PortalState  // Portal da sala, controlado pelo servidor
+	Moeda              shared.CoinState    // Moeda da sala, controlada pelo servidor
+	Inimigos           []shared.EnemyState // Inimigos da sala, movidos pelo servidor
+	MostrarPlacar      bool                // Placar aberto sobre o mapa
+	Placar             []shared.LeaderboardEntry
+	Chat               []shared.ChatMessage // Últimas mensagens da sala, da mais antiga para a mais nova
+	ChatSeq            int64                // Última mensagem recebida do servidor
+	ChatRolagem        int                  // Quantas mensagens o painel está rolado para cima
+	Digitando          bool                 // Modo de digitação do chat
+	ChatTexto          []rune               // Mensagem sendo digitada
 
 	Players map[int]shared.PlayerState
 }
@@ -0,0 +1,218 @@
+// gerarcert cria uma CA local e um certificado do servidor assinado por ela,
+// para rodar o jogo com TLS sem depender de uma autoridade de verdade:
+//
+//	go run ./gerarcert -dir certs -hosts localhost,127.0.0.1
+//	go run ./server -tls-cert certs/servidor.pem -tls-key certs/servidor-chave.pem
+//	go run ./client -ca certs/ca.pem
+//
+// Se a CA já existir no diretório ela é reaproveitada, assim os clientes
+// que confiam nela continuam funcionando quando o certificado é renovado.
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"jogo/shared"
+)
+
+// Validade da CA; a do servidor vem da flag -validade
+const validadeCA = 10 * 365 * 24 * time.Hour
+
+func main() {
+	dir := flag.String("dir", "certs", "diretório onde os arquivos são gravados")
+	hosts := flag.String("hosts", "localhost,127.0.0.1,::1", "nomes e IPs do servidor, separados por vírgula")
+	validade := flag.Duration("validade", 365*24*time.Hour, "validade do certificado do servidor")
+	flag.Parse()
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		log.Fatal(err)
+	}
+	caArq := filepath.Join(*dir, "ca.pem")
+	caChaveArq := filepath.Join(*dir, "ca-chave.pem")
+
+	ca, caChave, err := lerCA(caArq, caChaveArq)
+	if errors.Is(err, os.ErrNotExist) {
+		ca, caChave, err = criarCA(caArq, caChaveArq)
+		if err == nil {
+			fmt.Printf("CA criada em %s\n", caArq)
+		}
+	} else if err == nil {
+		fmt.Printf("Reaproveitando a CA de %s\n", caArq)
+	}
+	if err != nil {
+		log.Fatal("Erro na CA: ", err)
+	}
+
+	cert, err := criarServidor(ca, caChave, strings.Split(*hosts, ","), *validade,
+		filepath.Join(*dir, "servidor.pem"), filepath.Join(*dir, "servidor-chave.pem"))
+	if err != nil {
+		log.Fatal("Erro no certificado do servidor: ", err)
+	}
+	fmt.Printf("Certificado do servidor em %s para %s\n", filepath.Join(*dir, "servidor.pem"), *hosts)
+	fmt.Printf("Pin para o cliente (-pin): %s\n", shared.PinCertificado(cert))
+}
+
+// novoSerial sorteia o número de série de um certificado
+func novoSerial() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+// criarCA gera a chave e o certificado autoassinado da CA
+func criarCA(certArq, chaveArq string) (*x509.Certificate, crypto.Signer, error) {
+	chave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := novoSerial()
+	if err != nil {
+		return nil, nil, err
+	}
+	agora := time.Now()
+	modelo := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "CA local do jogo"},
+		NotBefore:             agora.Add(-time.Hour),
+		NotAfter:              agora.Add(validadeCA),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, modelo, modelo, &chave.PublicKey, chave)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := gravar(certArq, chaveArq, der, chave); err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	return cert, chave, err
+}
+
+// lerCA carrega uma CA gerada antes. Só retorna os.ErrNotExist quando não há
+// nenhum dos dois arquivos: com um deles faltando, criar outra CA por cima
+// invalidaria a que os clientes já confiam.
+func lerCA(certArq, chaveArq string) (*x509.Certificate, crypto.Signer, error) {
+	temCert, err := existe(certArq)
+	if err != nil {
+		return nil, nil, err
+	}
+	temChave, err := existe(chaveArq)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case !temCert && !temChave:
+		return nil, nil, os.ErrNotExist
+	case !temChave:
+		return nil, nil, fmt.Errorf("%s existe mas %s não; restaure a chave ou apague os dois para criar outra CA", certArq, chaveArq)
+	case !temCert:
+		return nil, nil, fmt.Errorf("%s existe mas %s não; restaure o certificado ou apague os dois para criar outra CA", chaveArq, certArq)
+	}
+
+	certPEM, err := os.ReadFile(certArq)
+	if err != nil {
+		return nil, nil, err
+	}
+	chavePEM, err := os.ReadFile(chaveArq)
+	if err != nil {
+		return nil, nil, err
+	}
+	bloco, _ := pem.Decode(certPEM)
+	if bloco == nil {
+		return nil, nil, fmt.Errorf("%s não contém um certificado", certArq)
+	}
+	cert, err := x509.ParseCertificate(bloco.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	bloco, _ = pem.Decode(chavePEM)
+	if bloco == nil {
+		return nil, nil, fmt.Errorf("%s não contém uma chave", chaveArq)
+	}
+	chave, err := x509.ParsePKCS8PrivateKey(bloco.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	assinador, ok := chave.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: tipo de chave não suportado", chaveArq)
+	}
+	return cert, assinador, nil
+}
+
+// existe verifica se o arquivo está lá
+func existe(arq string) (bool, error) {
+	_, err := os.Stat(arq)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// criarServidor gera o certificado do servidor para hosts, assinado pela CA
+func criarServidor(ca *x509.Certificate, caChave crypto.Signer, hosts []string, validade time.Duration, certArq, chaveArq string) (*x509.Certificate, error) {
+	chave, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	serial, err := novoSerial()
+	if err != nil {
+		return nil, err
+	}
+	agora := time.Now()
+	modelo := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "servidor do jogo"},
+		NotBefore:    agora.Add(-time.Hour),
+		NotAfter:     agora.Add(validade),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range hosts {
+		h = strings.TrimSpace(h)
+		if ip := net.ParseIP(h); ip != nil {
+			modelo.IPAddresses = append(modelo.IPAddresses, ip)
+		} else if h != "" {
+			modelo.DNSNames = append(modelo.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, modelo, ca, &chave.PublicKey, caChave)
+	if err != nil {
+		return nil, err
+	}
+	if err := gravar(certArq, chaveArq, der, chave); err != nil {
+		return nil, err
+	}
+	return x509.ParseCertificate(der)
+}
+
+// gravar salva o certificado e a chave em PEM; a chave só pode ser lida pelo dono
+func gravar(certArq, chaveArq string, der []byte, chave crypto.Signer) error {
+	chaveDER, err := x509.MarshalPKCS8PrivateKey(chave)
+	if err != nil {
+		return err
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(certArq, certPEM, 0o644); err != nil {
+		return err
+	}
+	chavePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: chaveDER})
+	return os.WriteFile(chaveArq, chavePEM, 0o600)
+}
@@ -2,8 +2,13 @@ module jogo
 
 go 1.25.0
 
+require (
+	github.com/nsf/termbox-go v1.1.1
+	golang.org/x/term v0.45.0
+)
+
 require (
 	github.com/mattn/go-runewidth v0.0.16 // indirect
-	github.com/nsf/termbox-go v1.1.1 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
+	golang.org/x/sys v0.47.0 // indirect
 )
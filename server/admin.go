@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"jogo/shared"
+	"maps"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Ajuda do console de administração
+const adminAjuda = `Comandos:
+  list                 lista salas e jogadores
+  kick <id>            remove o jogador
+  ban <id|ip>          bane o IP (do jogador) e remove quem estiver nele
+  unban <ip>           retira o banimento
+  tp <id> <x> <y>      teletransporta o jogador
+  say <mensagem>       manda uma mensagem no chat de todas as salas
+  reload-map [sala]    relê o arquivo do mapa (de todas as salas, se omitida)
+  help                 mostra esta ajuda`
+
+// adminConsole lê comandos de administração de entrada, um por linha,
+// e escreve as respostas em saida. Termina quando a entrada acaba.
+func (st *ServerState) adminConsole(entrada io.Reader, saida io.Writer) {
+	scanner := bufio.NewScanner(entrada)
+	for scanner.Scan() {
+		campos := strings.Fields(scanner.Text())
+		if len(campos) == 0 {
+			continue
+		}
+		st.mu.Lock()
+		resposta := st.comandoAdmin(campos[0], campos[1:])
+		st.mu.Unlock()
+		fmt.Fprintln(saida, resposta)
+	}
+}
+
+// comandoAdmin executa um comando do console e retorna a resposta.
+// Deve ser chamada com mu travado.
+func (st *ServerState) comandoAdmin(cmd string, args []string) string {
+	switch cmd {
+	case "list":
+		return st.adminListar()
+
+	case "kick":
+		if len(args) != 1 {
+			return "uso: kick <id>"
+		}
+		id, err := strconv.Atoi(args[0])
+		if err != nil {
+			return "id inválido: " + args[0]
+		}
+		if _, ok := st.playerRoom[id]; !ok {
+			return fmt.Sprintf("jogador %d não existe", id)
+		}
+		if err := st.registrar(journalEntry{Op: opKick, PlayerID: id, Reason: "expulso pelo administrador"}); err != nil {
+			return "erro: " + err.Error()
+		}
+		return fmt.Sprintf("jogador %d expulso", id)
+
+	case "ban":
+		if len(args) != 1 {
+			return "uso: ban <id|ip>"
+		}
+		ip := args[0]
+		if id, err := strconv.Atoi(ip); err == nil {
+			var ok bool
+			if ip, ok = st.enderecos[id]; !ok {
+				return fmt.Sprintf("endereço do jogador %d desconhecido", id)
+			}
+		} else if net.ParseIP(ip) == nil {
+			return "nem id nem IP: " + ip
+		}
+		st.banidos[ip] = true
+		// Quem já está conectado desse endereço sai junto
+		for _, id := range slices.Sorted(maps.Keys(st.enderecos)) {
+			if st.enderecos[id] != ip {
+				continue
+			}
+			if err := st.registrar(journalEntry{Op: opKick, PlayerID: id, Reason: "banido pelo administrador"}); err != nil {
+				return "erro: " + err.Error()
+			}
+		}
+		logInfo("[Admin] IP %s banido", ip)
+		return fmt.Sprintf("%s banido", ip)
+
+	case "unban":
+		if len(args) != 1 {
+			return "uso: unban <ip>"
+		}
+		if !st.banidos[args[0]] {
+			return args[0] + " não estava banido"
+		}
+		delete(st.banidos, args[0])
+		logInfo("[Admin] IP %s desbanido", args[0])
+		return args[0] + " desbanido"
+
+	case "tp":
+		if len(args) != 3 {
+			return "uso: tp <id> <x> <y>"
+		}
+		var nums [3]int
+		for i, a := range args {
+			n, err := strconv.Atoi(a)
+			if err != nil {
+				return "número inválido: " + a
+			}
+			nums[i] = n
+		}
+		id, x, y := nums[0], nums[1], nums[2]
+		room, ok := st.playerRoom[id]
+		if !ok {
+			return fmt.Sprintf("jogador %d não existe", id)
+		}
+		if !room.celulaLivre(x, y, id) {
+			return fmt.Sprintf("(%d, %d) não está livre na sala %s", x, y, room.nome)
+		}
+		if err := st.registrar(journalEntry{Op: opTeleport, Room: room.nome, PlayerID: id, X: x, Y: y}); err != nil {
+			return "erro: " + err.Error()
+		}
+		logInfo("[Admin] ID %d teletransportado para (%d, %d)", id, x, y)
+		return fmt.Sprintf("jogador %d em (%d, %d)", id, x, y)
+
+	case "say":
+		texto := limparMensagem(strings.Join(args, " "))
+		if texto == "" {
+			return "uso: say <mensagem>"
+		}
+		for _, room := range st.rooms {
+			room.adicionarMensagem(0, texto)
+		}
+		logInfo("[Admin] Aviso para todas as salas: %s", texto)
+		return "mensagem enviada"
+
+	case "reload-map":
+		if len(args) > 1 {
+			return "uso: reload-map [sala]"
+		}
+		nomes := slices.Sorted(maps.Keys(st.rooms))
+		if len(args) == 1 {
+			if _, ok := st.rooms[args[0]]; !ok {
+				return "sala não encontrada: " + args[0]
+			}
+			nomes = args
+		}
+		var linhas []string
+		for _, nome := range nomes {
+			linhas = append(linhas, st.adminRecarregarMapa(st.rooms[nome]))
+		}
+		return strings.Join(linhas, "\n")
+
+	case "help":
+		return adminAjuda
+	}
+	return fmt.Sprintf("comando desconhecido %q, use help", cmd)
+}
+
+// adminListar descreve as salas e seus jogadores. Deve ser chamada com mu travado.
+func (st *ServerState) adminListar() string {
+	var b strings.Builder
+	for _, nome := range slices.Sorted(maps.Keys(st.rooms)) {
+		room := st.rooms[nome]
+		fmt.Fprintf(&b, "sala %s (%s): %d jogadores\n", nome, room.mapaArquivo, len(room.players))
+		for _, id := range slices.Sorted(maps.Keys(room.players)) {
+			p := room.players[id]
+			vida := fmt.Sprintf("vida %d/%d", p.HP, shared.MaxHP)
+			if p.Dead {
+				vida = "morto"
+			}
+			visto := time.Since(st.lastSeen[id]).Round(time.Second)
+			fmt.Fprintf(&b, "  ID %d %s (%s) em (%d, %d), %s, %d moedas, visto há %v\n",
+				id, cmp.Or(st.contaDe[id], "?"), cmp.Or(st.enderecos[id], "?"), p.PosX, p.PosY, vida, p.Score, visto)
+		}
+	}
+	if st.descartados > 0 {
+		fmt.Fprintf(&b, "movimentos descartados pelo limite: %d\n", st.descartados)
+	}
+	if len(st.banidos) > 0 {
+		fmt.Fprintf(&b, "banidos: %s\n", strings.Join(slices.Sorted(maps.Keys(st.banidos)), ", "))
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// adminRecarregarMapa relê o mapa da sala e tira de dentro das paredes
+// os jogadores que ficaram presos nelas. Deve ser chamada com mu travado.
+func (st *ServerState) adminRecarregarMapa(room *Room) string {
+	// Valida antes de registrar, um mapa quebrado não entra no journal
+	mapa, err := carregarMapa(room.mapaArquivo)
+	if err != nil {
+		return fmt.Sprintf("sala %s: erro ao ler %s: %v", room.nome, room.mapaArquivo, err)
+	}
+	// O conteúdo vai junto na entrada: o replay não pode depender do arquivo de agora
+	if err := st.registrar(journalEntry{Op: opReloadMap, Room: room.nome, Lines: mapa.Linhas}); err != nil {
+		return "erro: " + err.Error()
+	}
+
+	movidos := 0
+	for _, id := range slices.Sorted(maps.Keys(room.players)) {
+		p := room.players[id]
+		if room.celulaLivre(p.PosX, p.PosY, id) {
+			continue
+		}
+		destino, ok := room.livreMaisProxima(Posicao{p.PosX, p.PosY})
+		if !ok {
+			destino, ok = room.escolherSpawn()
+		}
+		if !ok {
+			logAviso("[Admin] Nenhuma célula livre para o ID %d na sala %s", id, room.nome)
+			continue
+		}
+		if err := st.registrar(journalEntry{Op: opTeleport, Room: room.nome, PlayerID: id, X: destino.X, Y: destino.Y}); err != nil {
+			return "erro: " + err.Error()
+		}
+		movidos++
+	}
+	logInfo("[Admin] Mapa da sala %s recarregado de %s", room.nome, room.mapaArquivo)
+	return fmt.Sprintf("sala %s: mapa recarregado, %d jogadores movidos", room.nome, movidos)
+}
+
+// teletransportar move o jogador para (x, y) por decisão do servidor.
+// Deve ser chamada com mu travado.
+func (room *Room) teletransportar(id, x, y int) {
+	p, ok := room.players[id]
+	if !ok {
+		return
+	}
+	p.PosX, p.PosY = x, y
+	p.Warps++ // Avisa o cliente que a posição local não vale mais
+	room.players[id] = p
+	room.recordChange(id, changeMoved)
+}
+
+// recarregarMapa troca o mapa da sala pelo mapa de linhas.
+// Inimigos e pato voltam às posições do mapa novo; moeda e portal que
+// ficaram dentro de paredes somem. Deve ser chamada com mu travado.
+func (room *Room) recarregarMapa(linhas []string) error {
+	mapa, err := montarMapa(linhas)
+	if err != nil {
+		return err
+	}
+	room.mapa = mapa
+	room.mapaVersao++
+
+	room.inimigos = nil
+	for _, p := range mapa.Inimigos {
+		room.inimigos = append(room.inimigos, shared.EnemyState{X: p.X, Y: p.Y})
+	}
+	room.pato = shared.DuckState{}
+	if mapa.Pato != nil {
+		room.pato = shared.DuckState{Present: true, X: mapa.Pato.X, Y: mapa.Pato.Y}
+	}
+	if room.moeda.Active && (!mapa.dentro(room.moeda.X, room.moeda.Y) || mapa.tangivel(room.moeda.X, room.moeda.Y)) {
+		room.moeda.Active = false
+		room.moeda.LastTakenBy = 0
+	}
+	if room.portal.Active && (!mapa.dentro(room.portal.X, room.portal.Y) || mapa.tangivel(room.portal.X, room.portal.Y)) {
+		room.portal.Active = false
+		room.portal.LastUsedBy = 0
+	}
+	room.recordChange(0, changeMap)
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"jogo/shared"
+	"strings"
+	"unicode"
+)
+
+const (
+	chatSize   = 64  // Mensagens guardadas por sala; as mais antigas são sobrescritas
+	chatMaxLen = 120 // Tamanho máximo de uma mensagem, em caracteres
+)
+
+// limparMensagem tira caracteres de controle e corta mensagens longas demais
+func limparMensagem(texto string) string {
+	texto = strings.Map(func(r rune) rune {
+		if !unicode.IsPrint(r) {
+			return -1
+		}
+		return r
+	}, texto)
+	texto = strings.TrimSpace(texto)
+	if r := []rune(texto); len(r) > chatMaxLen {
+		texto = string(r[:chatMaxLen])
+	}
+	return texto
+}
+
+// adicionarMensagem guarda a mensagem no buffer circular da sala.
+// Deve ser chamada com mu travado.
+func (room *Room) adicionarMensagem(id int, texto string) int64 {
+	room.chatSeq++
+	room.chat[room.chatSeq%chatSize] = shared.ChatMessage{Seq: room.chatSeq, PlayerID: id, Text: texto}
+	// Quem está no long-poll fica sabendo pelo ChatSeq do delta
+	room.recordChange(0, changeWorld)
+	return room.chatSeq
+}
+
+// mensagensDesde retorna as mensagens ainda no buffer com Seq maior que since.
+// Deve ser chamada com mu travado.
+func (room *Room) mensagensDesde(since int64) []shared.ChatMessage {
+	inicio := max(since, room.chatSeq-chatSize, 0) + 1
+	var msgs []shared.ChatMessage
+	for seq := inicio; seq <= room.chatSeq; seq++ {
+		msgs = append(msgs, room.chat[seq%chatSize])
+	}
+	return msgs
+}
+
+// SendChat manda uma mensagem para todos da sala do jogador
+func (s *GameService) SendChat(args *shared.SendChatArgs, reply *shared.SendChatReply) error {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	id := s.state.jogadorDa(args.SessionToken)
+	room, ok := s.state.playerRoom[id]
+	if !ok || !s.state.touch(id) {
+		return errors.New(shared.ErrSessionExpired)
+	}
+	texto := limparMensagem(args.Text)
+	if texto == "" {
+		return errors.New(shared.ErrChatEmpty)
+	}
+
+	reply.Seq = room.adicionarMensagem(id, texto)
+	logInfo("[Chat] ID %d na sala %s: %s", id, room.nome, texto)
+	return nil
+}
+
+// GetChat retorna as mensagens da sala do jogador depois de Since
+func (s *GameService) GetChat(args *shared.GetChatArgs, reply *shared.GetChatReply) error {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	_, room, err := s.state.salaDaSessao(args.SessionToken)
+	if err != nil {
+		return err
+	}
+	reply.Messages = room.mensagensDesde(args.Since)
+	return nil
+}
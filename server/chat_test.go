@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestChatBufferCircular(t *testing.T) {
+	room := salaTeste(t, "▤▤▤▤▤", "▤   ▤", "▤▤▤▤▤")
+
+	if msgs := room.mensagensDesde(0); len(msgs) != 0 {
+		t.Fatalf("sala nova com %d mensagens", len(msgs))
+	}
+
+	// Passa da capacidade para o buffer dar a volta
+	total := chatSize + 10
+	for i := 1; i <= total; i++ {
+		if seq := room.adicionarMensagem(1, fmt.Sprintf("msg %d", i)); seq != int64(i) {
+			t.Fatalf("mensagem %d com seq %d", i, seq)
+		}
+	}
+
+	casos := []struct {
+		nome     string
+		since    int64
+		primeira int64 // Seq da primeira mensagem esperada, 0 se nenhuma
+	}{
+		{"do começo, só cabem as últimas", 0, int64(total - chatSize + 1)},
+		{"antes do que sobrou no buffer", 5, int64(total - chatSize + 1)},
+		{"no meio", int64(total - 3), int64(total - 2)},
+		{"em dia", int64(total), 0},
+		{"à frente", int64(total + 5), 0},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			msgs := room.mensagensDesde(c.since)
+			if c.primeira == 0 {
+				if len(msgs) != 0 {
+					t.Fatalf("%d mensagens, esperava nenhuma", len(msgs))
+				}
+				return
+			}
+			if want := int64(total) - c.primeira + 1; int64(len(msgs)) != want {
+				t.Fatalf("%d mensagens, esperava %d", len(msgs), want)
+			}
+			// Em ordem, sem buracos, cada uma com o próprio texto
+			for i, m := range msgs {
+				seq := c.primeira + int64(i)
+				if m.Seq != seq || m.Text != fmt.Sprintf("msg %d", seq) {
+					t.Fatalf("mensagem %d = %+v, esperava seq %d", i, m, seq)
+				}
+			}
+		})
+	}
+}
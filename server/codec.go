@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"encoding/gob"
+	"io"
+	"net/rpc"
+	"strings"
+	"sync"
+	"time"
+)
+
+// codecGob é o mesmo codec gob do net/rpc, que não é exportado.
+// Recriado aqui para podermos envolvê-lo com o codecMedido.
+type codecGob struct {
+	rwc    io.ReadWriteCloser
+	dec    *gob.Decoder
+	enc    *gob.Encoder
+	encBuf *bufio.Writer
+	closed bool
+}
+
+func novoCodecGob(conn io.ReadWriteCloser) rpc.ServerCodec {
+	buf := bufio.NewWriter(conn)
+	return &codecGob{rwc: conn, dec: gob.NewDecoder(conn), enc: gob.NewEncoder(buf), encBuf: buf}
+}
+
+func (c *codecGob) ReadRequestHeader(r *rpc.Request) error {
+	return c.dec.Decode(r)
+}
+
+func (c *codecGob) ReadRequestBody(body any) error {
+	return c.dec.Decode(body)
+}
+
+func (c *codecGob) WriteResponse(r *rpc.Response, body any) error {
+	if err := c.enc.Encode(r); err != nil {
+		// Erro de codificação: fecha a conexão, como o net/rpc faz
+		if c.encBuf.Flush() == nil {
+			c.Close()
+		}
+		return err
+	}
+	if err := c.enc.Encode(body); err != nil {
+		if c.encBuf.Flush() == nil {
+			c.Close()
+		}
+		return err
+	}
+	return c.encBuf.Flush()
+}
+
+func (c *codecGob) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+	return c.rwc.Close()
+}
+
+// codecMedido mede quanto cada chamada leva entre chegar e ser respondida
+type codecMedido struct {
+	rpc.ServerCodec
+	metricas *Metricas
+
+	mu     sync.Mutex
+	inicio map[uint64]time.Time // Por Seq da requisição
+}
+
+func medirCodec(codec rpc.ServerCodec, m *Metricas) *codecMedido {
+	return &codecMedido{ServerCodec: codec, metricas: m, inicio: make(map[uint64]time.Time)}
+}
+
+func (c *codecMedido) ReadRequestHeader(r *rpc.Request) error {
+	err := c.ServerCodec.ReadRequestHeader(r)
+	if err == nil {
+		c.mu.Lock()
+		c.inicio[r.Seq] = time.Now()
+		c.mu.Unlock()
+	}
+	return err
+}
+
+func (c *codecMedido) WriteResponse(r *rpc.Response, body any) error {
+	c.mu.Lock()
+	inicio, ok := c.inicio[r.Seq]
+	delete(c.inicio, r.Seq)
+	c.mu.Unlock()
+
+	if ok {
+		metodo := r.ServiceMethod
+		// Erros do próprio net/rpc (método inexistente...) não viram um rótulo por nome inventado
+		if strings.HasPrefix(r.Error, "rpc: ") {
+			metodo = "desconhecido"
+		}
+		c.metricas.observarChamada(metodo, time.Since(inicio), r.Error != "")
+	}
+	return c.ServerCodec.WriteResponse(r, body)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// Duracao é um time.Duration escrito como "30s" no arquivo de configuração
+type Duracao struct {
+	time.Duration
+}
+
+func (d Duracao) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duracao) UnmarshalJSON(dados []byte) error {
+	var s string
+	if err := json.Unmarshal(dados, &s); err != nil {
+		return err
+	}
+	var err error
+	d.Duration, err = time.ParseDuration(s)
+	return err
+}
+
+// Config reúne as opções do servidor, vindas das flags e do arquivo de configuração
+type Config struct {
+	Listen           string   `json:"listen"`            // Endereço do servidor RPC
+	JSONListen       string   `json:"json_listen"`       // Endereço do JSON-RPC, vazio desativa
+	HTTPRPCAddr      string   `json:"http_rpc_addr"`     // Endereço do JSON-RPC por HTTP POST, vazio desativa
+	TLSCert          string   `json:"tls_cert"`          // Certificado do servidor em PEM, vazio desativa o TLS
+	TLSKey           string   `json:"tls_key"`           // Chave do certificado em PEM
+	Mapa             string   `json:"map"`               // Mapa da sala padrão
+	MaxPlayers       int      `json:"max_players"`       // Jogadores somando todas as salas, 0 sem limite
+	TickRate         float64  `json:"tick_rate"`         // Verificações por segundo de portal e renascimento
+	IdleTimeout      Duracao  `json:"idle_timeout"`      // Remove jogadores sem sinal de vida
+	LogLevel         string   `json:"log_level"`         // debug, info, warn ou error
+	State            string   `json:"state"`             // Snapshot do estado, vazio desativa
+	SnapshotInterval Duracao  `json:"snapshot_interval"` // Intervalo entre snapshots e salvamentos do placar
+	Journal          string   `json:"journal"`           // Journal dos comandos, vazio desativa
+	EnemySpeed       float64  `json:"enemy_speed"`       // Passos por segundo dos inimigos
+	EnemyAggro       int      `json:"enemy_aggro"`       // Distância em que os inimigos perseguem
+	Leaderboard      string   `json:"leaderboard"`       // Placar permanente, vazio desativa
+	Accounts         string   `json:"accounts"`          // Cadastro de usuários, vazio mantém só em memória
+	MoveRate         float64  `json:"move_rate"`         // Movimentos por segundo por jogador, 0 sem limite
+	MoveBurst        int      `json:"move_burst"`        // Movimentos seguidos permitidos acima da taxa
+	Admin            bool     `json:"admin"`             // Console de administração na entrada padrão
+	MetricsAddr      string   `json:"metrics_addr"`      // Endereço HTTP do /metrics, vazio desativa
+	SpectatorAddr    string   `json:"spectator_addr"`    // Endereço HTTP da página do espectador, vazio desativa
+	Banned           []string `json:"banned"`            // IPs recusados no Connect, só pelo arquivo
+}
+
+// configPadrao retorna a configuração usada quando nada é informado
+func configPadrao() Config {
+	return Config{
+		Listen:           ":12345",
+		JSONListen:       ":12346",
+		Mapa:             "client/mapa.txt",
+		TickRate:         2,
+		IdleTimeout:      Duracao{30 * time.Second},
+		LogLevel:         "info",
+		State:            "server_state.json",
+		SnapshotInterval: Duracao{10 * time.Second},
+		Journal:          "server_journal.jsonl",
+		EnemySpeed:       2,
+		EnemyAggro:       8,
+		Leaderboard:      "leaderboard.json",
+		Accounts:         "accounts.json",
+		MoveRate:         12,
+		MoveBurst:        8,
+		Admin:            true,
+		MetricsAddr:      "127.0.0.1:2112",
+		SpectatorAddr:    "127.0.0.1:8080",
+	}
+}
+
+// registrarFlags liga cada opção a uma flag, com o valor atual como padrão
+func (c *Config) registrarFlags(fs *flag.FlagSet) {
+	fs.StringVar(&c.Listen, "listen", c.Listen, "endereço em que o servidor RPC escuta")
+	fs.StringVar(&c.JSONListen, "json-listen", c.JSONListen, "endereço do JSON-RPC (vazio desativa)")
+	fs.StringVar(&c.HTTPRPCAddr, "http-rpc", c.HTTPRPCAddr, "endereço do JSON-RPC por HTTP POST em /rpc (vazio desativa)")
+	fs.StringVar(&c.TLSCert, "tls-cert", c.TLSCert, "certificado PEM para aceitar RPC só por TLS (vazio desativa)")
+	fs.StringVar(&c.TLSKey, "tls-key", c.TLSKey, "chave PEM do certificado de -tls-cert")
+	fs.StringVar(&c.Mapa, "mapa", c.Mapa, "arquivo do mapa usado pelos clientes")
+	fs.IntVar(&c.MaxPlayers, "max-players", c.MaxPlayers, "máximo de jogadores conectados (0 sem limite)")
+	fs.Float64Var(&c.TickRate, "tick-rate", c.TickRate, "verificações por segundo de portal e renascimento")
+	fs.DurationVar(&c.IdleTimeout.Duration, "idle-timeout", c.IdleTimeout.Duration, "remove jogadores sem sinal de vida por esse tempo")
+	fs.StringVar(&c.LogLevel, "log-level", c.LogLevel, "nível de log: debug, info, warn ou error")
+	fs.StringVar(&c.State, "state", c.State, "arquivo onde o estado é salvo e restaurado (vazio desativa)")
+	fs.DurationVar(&c.SnapshotInterval.Duration, "snapshot-interval", c.SnapshotInterval.Duration, "intervalo entre salvamentos do estado")
+	fs.StringVar(&c.Journal, "journal", c.Journal, "journal dos comandos aceitos (vazio desativa)")
+	fs.Float64Var(&c.EnemySpeed, "enemy-speed", c.EnemySpeed, "passos por segundo dos inimigos")
+	fs.IntVar(&c.EnemyAggro, "enemy-aggro", c.EnemyAggro, "distância em passos a partir da qual os inimigos perseguem")
+	fs.StringVar(&c.Leaderboard, "leaderboard", c.Leaderboard, "arquivo do placar permanente (vazio desativa)")
+	fs.StringVar(&c.Accounts, "accounts", c.Accounts, "arquivo com as contas dos jogadores (vazio mantém só em memória)")
+	fs.Float64Var(&c.MoveRate, "move-rate", c.MoveRate, "movimentos por segundo por jogador (0 sem limite)")
+	fs.IntVar(&c.MoveBurst, "move-burst", c.MoveBurst, "movimentos seguidos permitidos acima da taxa")
+	fs.BoolVar(&c.Admin, "admin", c.Admin, "lê comandos de administração da entrada padrão")
+	fs.StringVar(&c.MetricsAddr, "metrics", c.MetricsAddr, "endereço HTTP do /metrics do Prometheus (vazio desativa)")
+	fs.StringVar(&c.SpectatorAddr, "spectator", c.SpectatorAddr, "endereço HTTP da página do espectador (vazio desativa)")
+}
+
+// lerConfig monta a configuração: padrões, depois o arquivo de -config,
+// depois as flags passadas na linha de comando, que têm a palavra final
+func lerConfig() (Config, error) {
+	cfg := configPadrao()
+	arquivo := flag.String("config", "", "arquivo JSON de configuração (as flags têm prioridade sobre ele)")
+	cfg.registrarFlags(flag.CommandLine)
+	flag.Parse()
+
+	if *arquivo != "" {
+		dados, err := os.ReadFile(*arquivo)
+		if err != nil {
+			return cfg, err
+		}
+		dec := json.NewDecoder(bytes.NewReader(dados))
+		dec.DisallowUnknownFields() // Pega erros de digitação nas chaves
+		if err := dec.Decode(&cfg); err != nil {
+			return cfg, fmt.Errorf("%s: %w", *arquivo, err)
+		}
+		// Reaplica só as flags explícitas por cima do arquivo
+		flag.Parse()
+	}
+	return cfg, cfg.validar()
+}
+
+// validar confere os valores e retorna todos os problemas encontrados
+func (c Config) validar() error {
+	var erros []error
+	if _, _, err := net.SplitHostPort(c.Listen); err != nil {
+		erros = append(erros, fmt.Errorf("listen %q inválido: %w", c.Listen, err))
+	}
+	opcionais := []struct{ nome, valor string }{
+		{"json_listen", c.JSONListen},
+		{"http_rpc_addr", c.HTTPRPCAddr},
+		{"metrics_addr", c.MetricsAddr},
+		{"spectator_addr", c.SpectatorAddr},
+	}
+	for _, o := range opcionais {
+		if o.valor == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(o.valor); err != nil {
+			erros = append(erros, fmt.Errorf("%s %q inválido: %w", o.nome, o.valor, err))
+		}
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		erros = append(erros, errors.New("tls_cert e tls_key devem ser informados juntos"))
+	}
+	if c.Mapa == "" {
+		erros = append(erros, errors.New("map não pode ser vazio"))
+	}
+	if c.MaxPlayers < 0 {
+		erros = append(erros, errors.New("max_players não pode ser negativo"))
+	}
+	if c.TickRate <= 0 {
+		erros = append(erros, errors.New("tick_rate deve ser positivo"))
+	}
+	if c.IdleTimeout.Duration <= 0 {
+		erros = append(erros, errors.New("idle_timeout deve ser positivo"))
+	}
+	if _, ok := niveisLog[c.LogLevel]; !ok {
+		erros = append(erros, fmt.Errorf("log_level %q inválido, use debug, info, warn ou error", c.LogLevel))
+	}
+	if c.SnapshotInterval.Duration <= 0 {
+		erros = append(erros, errors.New("snapshot_interval deve ser positivo"))
+	}
+	if c.EnemySpeed <= 0 {
+		erros = append(erros, errors.New("enemy_speed deve ser positivo"))
+	}
+	if c.EnemyAggro < 0 {
+		erros = append(erros, errors.New("enemy_aggro não pode ser negativo"))
+	}
+	if c.MoveRate < 0 {
+		erros = append(erros, errors.New("move_rate não pode ser negativo"))
+	}
+	if c.MoveRate > 0 && c.MoveBurst < 1 {
+		erros = append(erros, errors.New("move_burst deve ser pelo menos 1"))
+	}
+	for _, ip := range c.Banned {
+		if net.ParseIP(ip) == nil {
+			erros = append(erros, fmt.Errorf("banned: %q não é um IP", ip))
+		}
+	}
+	return errors.Join(erros...)
+}
+
+// tick é o intervalo entre as verificações dos managers de portal e renascimento
+func (c Config) tick() time.Duration {
+	return time.Duration(float64(time.Second) / c.TickRate)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"jogo/shared"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Parâmetros do hash das senhas. Cada conta guarda as iterações com que foi
+// criada, então aumentar iteracoesSenha não invalida as contas antigas.
+const (
+	iteracoesSenha = 600_000
+	tamanhoSal     = 16
+	tamanhoHash    = 32
+	maxSenha       = 256 // Não deixa uma senha enorme custar caro no PBKDF2
+	maxContas      = 10_000
+)
+
+// Cadastros por endereço: cada um custa um hash lento e uma gravação do
+// arquivo inteiro, então um endereço faz 3 seguidos e depois 1 por minuto
+var limiteCadastros = limiteMovimentos{taxa: 1.0 / 60, rajada: 3}
+
+// Nomes de usuário aceitos pelo Register
+var nomeUsuarioValido = regexp.MustCompile(`^[A-Za-z0-9_-]{3,20}$`)
+
+// conta é o que fica salvo de cada usuário; a senha nunca é guardada
+type conta struct {
+	Usuario   string `json:"username"`
+	Sal       []byte `json:"salt"`
+	Hash      []byte `json:"hash"` // PBKDF2-SHA256 da senha com Sal
+	Iteracoes int    `json:"iterations"`
+}
+
+// Contas é o cadastro de usuários, gravado em path a cada conta nova.
+// Tem trava própria para não segurar ServerState.mu enquanto grava o arquivo.
+type Contas struct {
+	mu        sync.Mutex
+	path      string // Vazio mantém as contas só em memória
+	contas    map[string]conta
+	max       int                     // Máximo de contas, maxContas fora dos testes
+	cadastros map[string]*tokenBucket // Fichas de cadastro de cada endereço
+}
+
+// abrirContas carrega o cadastro salvo em path, se existir
+func abrirContas(path string) (*Contas, error) {
+	c := &Contas{path: path, contas: make(map[string]conta), max: maxContas, cadastros: make(map[string]*tokenBucket)}
+	if path == "" {
+		return c, nil
+	}
+	dados, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var lista []conta
+	if err := json.Unmarshal(dados, &lista); err != nil {
+		return nil, err
+	}
+	for _, ct := range lista {
+		c.contas[ct.Usuario] = ct
+	}
+	logInfo("[Contas] %d contas carregadas de %s", len(lista), path)
+	return c, nil
+}
+
+// hashSenha deriva o hash da senha. É lento de propósito.
+func hashSenha(senha string, sal []byte, iteracoes int) []byte {
+	hash, err := pbkdf2.Key(sha256.New, senha, sal, iteracoes, tamanhoHash)
+	if err != nil {
+		// Só acontece com parâmetros fora do permitido pelo modo FIPS
+		panic(err)
+	}
+	return hash
+}
+
+// criar cadastra o usuário pedido por remoto e grava o arquivo antes de responder
+func (c *Contas) criar(remoto, usuario, senha string) error {
+	if !nomeUsuarioValido.MatchString(usuario) {
+		return errors.New(shared.ErrUsernameBad)
+	}
+	if utf8.RuneCountInString(senha) < shared.MinPasswordLen || len(senha) > maxSenha {
+		return errors.New(shared.ErrPasswordShort)
+	}
+	c.mu.Lock()
+	err := c.podeCriar(usuario)
+	if err == nil {
+		err = c.reservarCadastro(remoto, time.Now())
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// O hash é calculado sem a trava, outros logins não esperam por ele
+	sal := make([]byte, tamanhoSal)
+	rand.Read(sal)
+	nova := conta{Usuario: usuario, Sal: sal, Hash: hashSenha(senha, sal, iteracoesSenha), Iteracoes: iteracoesSenha}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := c.podeCriar(usuario); err != nil {
+		return err // Outro cadastro chegou antes
+	}
+	c.contas[usuario] = nova
+	if err := c.salvar(); err != nil {
+		delete(c.contas, usuario)
+		logErro("[Contas] Erro ao salvar %s: %v", c.path, err)
+		return err
+	}
+	return nil
+}
+
+// podeCriar confere se o nome está livre e ainda cabe uma conta.
+// Deve ser chamada com c.mu travado.
+func (c *Contas) podeCriar(usuario string) error {
+	if _, existe := c.contas[usuario]; existe {
+		return errors.New(shared.ErrUsernameTaken)
+	}
+	if len(c.contas) >= c.max {
+		return errors.New(shared.ErrAccountLimit)
+	}
+	return nil
+}
+
+// reservarCadastro gasta uma ficha de cadastro de remoto, sem esperar por ela.
+// Deve ser chamada com c.mu travado.
+func (c *Contas) reservarCadastro(remoto string, agora time.Time) error {
+	b, ok := c.cadastros[remoto]
+	if !ok {
+		// Esquece os endereços que já recuperaram todas as fichas
+		for end, outro := range c.cadastros {
+			if outro.fichas+agora.Sub(outro.ultimo).Seconds()*limiteCadastros.taxa >= limiteCadastros.rajada {
+				delete(c.cadastros, end)
+			}
+		}
+		b = &tokenBucket{fichas: limiteCadastros.rajada, ultimo: agora}
+		c.cadastros[remoto] = b
+	}
+	if _, ok := b.reservar(agora, limiteCadastros, 0); !ok {
+		return errors.New(shared.ErrRegisterTooFast)
+	}
+	return nil
+}
+
+// verificar confere usuário e senha
+func (c *Contas) verificar(usuario, senha string) bool {
+	c.mu.Lock()
+	ct, existe := c.contas[usuario]
+	c.mu.Unlock()
+	if !existe {
+		// Calcula um hash mesmo assim, para o tempo não revelar quais usuários existem
+		ct = conta{Sal: make([]byte, tamanhoSal), Hash: make([]byte, tamanhoHash), Iteracoes: iteracoesSenha}
+	}
+	if len(senha) > maxSenha {
+		return false
+	}
+	hash := hashSenha(senha, ct.Sal, ct.Iteracoes)
+	return subtle.ConstantTimeCompare(hash, ct.Hash) == 1 && existe
+}
+
+// salvar grava todas as contas. Deve ser chamada com c.mu travado.
+func (c *Contas) salvar() error {
+	if c.path == "" {
+		return nil
+	}
+	lista := make([]conta, 0, len(c.contas))
+	for _, nome := range slices.Sorted(maps.Keys(c.contas)) {
+		lista = append(lista, c.contas[nome])
+	}
+	dados, err := json.MarshalIndent(lista, "", "  ")
+	if err != nil {
+		return err
+	}
+	return escreverAtomico(c.path, dados)
+}
+
+// Register cria uma conta para usar no Connect
+func (s *GameService) Register(args *shared.RegisterArgs, reply *shared.RegisterReply) error {
+	if err := s.state.contas.criar(s.remoto, args.Username, args.Password); err != nil {
+		logInfo("[Contas] Cadastro de %q por %s recusado: %v", args.Username, s.remoto, err)
+		return err
+	}
+	logInfo("[Contas] Conta %q criada por %s", args.Username, s.remoto)
+	return nil
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"jogo/shared"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// contasTeste abre um cadastro vazio gravado num diretório temporário
+func contasTeste(t *testing.T) *Contas {
+	t.Helper()
+	c, err := abrirContas(filepath.Join(t.TempDir(), "contas.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestContasCriarEVerificar(t *testing.T) {
+	c := contasTeste(t)
+	if err := c.criar("10.0.0.1", "ana", "segredo123"); err != nil {
+		t.Fatal(err)
+	}
+	if !c.verificar("ana", "segredo123") {
+		t.Error("senha certa recusada")
+	}
+	if c.verificar("ana", "segredo124") {
+		t.Error("senha errada aceita")
+	}
+	if c.verificar("bia", "segredo123") {
+		t.Error("usuário inexistente aceito")
+	}
+
+	// O arquivo gravado guarda o hash, não a senha, e continua valendo ao reabrir
+	reaberta, err := abrirContas(c.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reaberta.verificar("ana", "segredo123") {
+		t.Error("senha recusada depois de reabrir o arquivo")
+	}
+}
+
+func TestContasCriarRecusa(t *testing.T) {
+	casos := []struct {
+		nome    string
+		usuario string
+		senha   string
+		erro    string
+	}{
+		{"nome repetido", "ana", "outrasenha", shared.ErrUsernameTaken},
+		{"nome curto", "an", "segredo123", shared.ErrUsernameBad},
+		{"nome longo", "abcdefghijklmnopqrstu", "segredo123", shared.ErrUsernameBad},
+		{"nome com espaço", "ana maria", "segredo123", shared.ErrUsernameBad},
+		{"nome com acento", "joão", "segredo123", shared.ErrUsernameBad},
+		{"senha curta", "bia", "1234567", shared.ErrPasswordShort},
+		{"senha enorme", "bia", string(make([]byte, maxSenha+1)), shared.ErrPasswordShort},
+	}
+
+	c := contasTeste(t)
+	if err := c.criar("10.0.0.1", "ana", "segredo123"); err != nil {
+		t.Fatal(err)
+	}
+	for i, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			// Um endereço por caso, para o limite de cadastros não interferir
+			remoto := fmt.Sprintf("10.0.1.%d", i)
+			err := c.criar(remoto, caso.usuario, caso.senha)
+			if err == nil || err.Error() != caso.erro {
+				t.Errorf("criar(%q) = %v, esperava %q", caso.usuario, err, caso.erro)
+			}
+		})
+	}
+	if len(c.contas) != 1 {
+		t.Errorf("%d contas, esperava só a primeira", len(c.contas))
+	}
+}
+
+func TestContasLimite(t *testing.T) {
+	c := contasTeste(t)
+	c.max = 1
+	if err := c.criar("10.0.0.1", "ana", "segredo123"); err != nil {
+		t.Fatal(err)
+	}
+	err := c.criar("10.0.0.2", "bia", "segredo123")
+	if err == nil || err.Error() != shared.ErrAccountLimit {
+		t.Fatalf("criar além do limite = %v, esperava %q", err, shared.ErrAccountLimit)
+	}
+}
+
+func TestContasLimiteDeCadastros(t *testing.T) {
+	c := contasTeste(t)
+	agora := time.Now()
+
+	// A rajada passa e a próxima é recusada antes de calcular qualquer hash
+	for i := range int(limiteCadastros.rajada) {
+		if err := c.reservarCadastro("10.0.0.1", agora); err != nil {
+			t.Fatalf("cadastro %d recusado: %v", i+1, err)
+		}
+	}
+	if err := c.reservarCadastro("10.0.0.1", agora); err == nil || err.Error() != shared.ErrRegisterTooFast {
+		t.Fatalf("cadastro além da rajada = %v, esperava %q", err, shared.ErrRegisterTooFast)
+	}
+
+	// Outro endereço tem as próprias fichas
+	if err := c.reservarCadastro("10.0.0.2", agora); err != nil {
+		t.Fatalf("outro endereço recusado: %v", err)
+	}
+
+	// Uma ficha volta depois de 1/taxa segundos
+	depois := agora.Add(time.Duration(float64(time.Second) / limiteCadastros.taxa))
+	if err := c.reservarCadastro("10.0.0.1", depois); err != nil {
+		t.Fatalf("cadastro depois da reposição recusado: %v", err)
+	}
+
+	// Pelo RPC a recusa também chega antes do hash
+	c.cadastros["10.0.0.3"] = &tokenBucket{ultimo: time.Now()}
+	err := c.criar("10.0.0.3", "ana", "segredo123")
+	if err == nil || err.Error() != shared.ErrRegisterTooFast {
+		t.Fatalf("criar sem fichas = %v, esperava %q", err, shared.ErrRegisterTooFast)
+	}
+	if _, existe := c.contas["ana"]; existe {
+		t.Error("conta criada apesar da recusa")
+	}
+}
+
+// Entrar de novo com a mesma conta derruba a sessão anterior
+func TestConnectDerrubaSessaoAnterior(t *testing.T) {
+	contas := contasTeste(t)
+	if err := contas.criar("10.0.0.1", "ana", "segredo123"); err != nil {
+		t.Fatal(err)
+	}
+	st := novoServerState(configPadrao(), salaTeste(t, "▤▤▤▤▤", "▤☺  ▤", "▤▤▤▤▤"), contas)
+	servico := &GameService{state: st, remoto: "10.0.0.1"}
+	args := &shared.ConnectArgs{Username: "ana", Password: "segredo123", Protocol: shared.ProtocolVersion}
+
+	primeira := &shared.ConnectReply{}
+	if err := servico.Connect(args, primeira); err != nil {
+		t.Fatal(err)
+	}
+	segunda := &shared.ConnectReply{}
+	if err := servico.Connect(args, segunda); err != nil {
+		t.Fatal(err)
+	}
+
+	batida := &shared.HeartbeatReply{}
+	servico.Heartbeat(&shared.HeartbeatArgs{SessionToken: primeira.SessionToken}, batida)
+	if batida.Known {
+		t.Error("a primeira sessão continua valendo")
+	}
+	servico.Heartbeat(&shared.HeartbeatArgs{SessionToken: segunda.SessionToken}, batida)
+	if !batida.Known {
+		t.Error("a segunda sessão não vale")
+	}
+	if len(segunda.AllPlayers) != 1 {
+		t.Errorf("%d jogadores na sala, esperava só o novo", len(segunda.AllPlayers))
+	}
+
+	err := servico.Connect(&shared.ConnectArgs{Username: "ana", Password: "errada123", Protocol: shared.ProtocolVersion}, &shared.ConnectReply{})
+	var re *shared.RejectError
+	if !errors.As(err, &re) || re.Reason != shared.RejectBadCredentials {
+		t.Errorf("Connect com senha errada = %v, esperava recusa por credenciais", err)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"jogo/shared"
+	"maps"
+)
+
+// Quantas mudanças ficam guardadas; clientes mais atrasados recebem o estado completo
+const maxChangeLog = 512
+
+// Tipos de mudança registradas no log
+type changeKind int
+
+const (
+	changeJoined changeKind = iota
+	changeMoved
+	changeLeft
+	changeWorld // Algo da sala que não é um jogador (moeda, portal...) mudou
+	changeMap   // O mapa da sala foi recarregado
+)
+
+// Uma entrada do log de mudanças
+type stateChange struct {
+	version  int64
+	playerID int
+	kind     changeKind
+}
+
+// recordChange registra uma mudança no jogador id e avança a versão.
+// Mudanças do mundo só avançam a versão: moeda, portal, pato e inimigos vão
+// inteiros em todo delta, e o tick dos inimigos encheria o log em minutos.
+// Deve ser chamada com mu travado.
+func (room *Room) recordChange(id int, kind changeKind) {
+	room.bump()
+	if kind == changeWorld {
+		return
+	}
+	room.changeLog = append(room.changeLog, stateChange{version: room.version, playerID: id, kind: kind})
+	if excesso := len(room.changeLog) - maxChangeLog; excesso > 0 {
+		room.logDesde = room.changeLog[excesso-1].version
+		room.changeLog = room.changeLog[excesso:]
+	}
+}
+
+// deltaSince monta as mudanças na sala desde a versão since.
+// Deve ser chamada com mu travado.
+func (room *Room) deltaSince(since int64) shared.StateDelta {
+	delta := shared.StateDelta{
+		Version:    room.version,
+		Coin:       room.moeda,
+		Portal:     room.portal,
+		Duck:       room.pato,
+		Enemies:    room.inimigos,
+		ChatSeq:    room.chatSeq,
+		MapVersion: room.mapaVersao,
+	}
+
+	// Cliente novo, de um servidor anterior ou atrasado demais: manda tudo
+	if since <= 0 || since > room.version || since < room.logDesde {
+		delta.Full = true
+		delta.MapLines = room.mapa.Linhas
+		delta.AllPlayers = make(map[int]shared.PlayerState)
+		maps.Copy(delta.AllPlayers, room.players)
+		return delta
+	}
+
+	// Compacta o log: o que importa é a última situação de cada jogador
+	entrou := make(map[int]bool)
+	tocados := make(map[int]bool)
+	for _, c := range room.changeLog {
+		if c.version <= since {
+			continue
+		}
+		if c.kind == changeMap {
+			delta.MapLines = room.mapa.Linhas
+			continue
+		}
+		tocados[c.playerID] = true
+		if c.kind == changeJoined {
+			entrou[c.playerID] = true
+		}
+	}
+
+	for id := range tocados {
+		p, existe := room.players[id]
+		switch {
+		case !existe && !entrou[id]:
+			delta.Left = append(delta.Left, id)
+		case !existe:
+			// Entrou e saiu dentro da janela, o cliente nunca o viu
+		case entrou[id]:
+			if delta.Joined == nil {
+				delta.Joined = make(map[int]shared.PlayerState)
+			}
+			delta.Joined[id] = p
+		default:
+			if delta.Moved == nil {
+				delta.Moved = make(map[int]shared.PlayerState)
+			}
+			delta.Moved[id] = p
+		}
+	}
+	return delta
+}
+
+// GetStateDelta retorna apenas o que mudou desde a versão conhecida pelo cliente
+func (s *GameService) GetStateDelta(args *shared.GetStateDeltaArgs, reply *shared.GetStateDeltaReply) error {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	_, room, err := s.state.salaDaSessao(args.SessionToken)
+	if err != nil {
+		return err
+	}
+	reply.StateDelta = room.deltaSince(args.SinceVersion)
+	return nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDeltaSince(t *testing.T) {
+	st := novoServerState(configPadrao(), salaTeste(t, "▤▤▤▤▤▤", "▤    ▤", "▤▤▤▤▤▤"), nil)
+	room := st.rooms[salaPadrao]
+	ana := jogadorTeste(t, st, "a", 1, 1)
+	bia := jogadorTeste(t, st, "b", 2, 1)
+	antes := room.version
+
+	// Em dia: nada de jogadores, mas o mundo vem junto
+	delta := room.deltaSince(room.version)
+	if delta.Full || delta.Joined != nil || delta.Moved != nil || delta.Left != nil {
+		t.Errorf("delta em dia = %+v, esperava vazio", delta)
+	}
+	if delta.Version != room.version {
+		t.Errorf("versão = %d, esperava %d", delta.Version, room.version)
+	}
+
+	// Um entra, um anda, um sai
+	if err := st.registrar(journalEntry{Op: opUpdate, PlayerID: ana, Seq: 1, X: 1, Y: 1, Accepted: true}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.registrar(journalEntry{Op: opDisconnect, PlayerID: bia}); err != nil {
+		t.Fatal(err)
+	}
+	cris := jogadorTeste(t, st, "c", 3, 1)
+	delta = room.deltaSince(antes)
+	if delta.Full {
+		t.Fatal("delta completo, esperava só as mudanças")
+	}
+	if _, ok := delta.Moved[ana]; !ok || len(delta.Moved) != 1 {
+		t.Errorf("movidos = %v, esperava só o %d", delta.Moved, ana)
+	}
+	if !slices.Equal(delta.Left, []int{bia}) {
+		t.Errorf("saíram = %v, esperava [%d]", delta.Left, bia)
+	}
+	if _, ok := delta.Joined[cris]; !ok || len(delta.Joined) != 1 {
+		t.Errorf("entraram = %v, esperava só o %d", delta.Joined, cris)
+	}
+
+	// Quem entrou e saiu dentro da janela não aparece
+	dani := jogadorTeste(t, st, "d", 4, 1)
+	if err := st.registrar(journalEntry{Op: opDisconnect, PlayerID: dani}); err != nil {
+		t.Fatal(err)
+	}
+	delta = room.deltaSince(antes)
+	if _, ok := delta.Joined[dani]; ok || slices.Contains(delta.Left, dani) {
+		t.Errorf("delta = %+v, o %d entrou e saiu e não devia aparecer", delta, dani)
+	}
+
+	// Versões de outro servidor ou de antes do começo: estado completo
+	for _, since := range []int64{0, room.version + 1} {
+		if delta := room.deltaSince(since); !delta.Full || len(delta.AllPlayers) != len(room.players) {
+			t.Errorf("deltaSince(%d) = %+v, esperava o estado completo", since, delta)
+		}
+	}
+}
+
+// Mais atrasado que o log, o cliente recebe tudo; os ticks do mundo não
+// ocupam o log e não empurram as mudanças dos jogadores para fora
+func TestDeltaSinceLogCheio(t *testing.T) {
+	st := novoServerState(configPadrao(), salaTeste(t, "▤▤▤▤▤", "▤   ▤", "▤▤▤▤▤"), nil)
+	room := st.rooms[salaPadrao]
+	ana := jogadorTeste(t, st, "a", 1, 1)
+	antes := room.version
+
+	for range 2 * maxChangeLog {
+		room.recordChange(0, changeWorld)
+	}
+	if len(room.changeLog) != 1 {
+		t.Errorf("log com %d entradas depois dos ticks do mundo, esperava só o connect", len(room.changeLog))
+	}
+	if delta := room.deltaSince(antes); delta.Full {
+		t.Error("delta completo depois de só mudanças do mundo")
+	}
+
+	meio := room.version
+	for range maxChangeLog + 1 {
+		room.recordChange(ana, changeMoved)
+	}
+	if len(room.changeLog) != maxChangeLog {
+		t.Errorf("log com %d entradas, esperava %d", len(room.changeLog), maxChangeLog)
+	}
+	if delta := room.deltaSince(meio); !delta.Full {
+		t.Error("cliente mais atrasado que o log recebeu só as mudanças")
+	}
+	// O mais antigo que o log ainda cobre
+	if delta := room.deltaSince(room.changeLog[0].version - 1); delta.Full {
+		t.Error("cliente coberto pelo log recebeu o estado completo")
+	}
+}
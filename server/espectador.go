@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/json"
+	"jogo/shared"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Página do espectador, servida em /
+//
+//go:embed espectador.html
+var paginaEspectador []byte
+
+// Intervalo mínimo entre dois quadros para o mesmo espectador
+const intervaloEspectador = 100 * time.Millisecond
+
+// Quanto um quadro pode demorar para sair antes de o espectador ser desligado
+const prazoEscritaEspectador = 10 * time.Second
+
+// quadroEspectador é o que o navegador recebe a cada mudança na sala
+type quadroEspectador struct {
+	Room    string                     `json:"room"`
+	Version int64                      `json:"version"`
+	Map     []string                   `json:"map,omitempty"` // Só no primeiro quadro e quando o mapa é recarregado
+	Players map[int]shared.PlayerState `json:"players"`
+	Coin    shared.CoinState           `json:"coin"`
+	Portal  shared.PortalState         `json:"portal"`
+	Duck    shared.DuckState           `json:"duck"`
+	Enemies []shared.EnemyState        `json:"enemies"`
+}
+
+// quadro copia o estado da sala para o espectador. Deve ser chamada com mu travado.
+func (room *Room) quadro(comMapa bool) quadroEspectador {
+	q := quadroEspectador{
+		Room:    room.nome,
+		Version: room.version,
+		Players: maps.Clone(room.players),
+		Coin:    room.moeda,
+		Portal:  room.portal,
+		Duck:    room.pato,
+		Enemies: slices.Clone(room.inimigos),
+	}
+	if comMapa {
+		q.Map = room.mapa.Linhas
+	}
+	return q
+}
+
+// servirEspectador expõe a página em / e o WebSocket da sala em /ws?sala=nome
+func (st *ServerState) servirEspectador(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(paginaEspectador)
+	})
+	mux.HandleFunc("GET /salas", func(w http.ResponseWriter, r *http.Request) {
+		st.mu.Lock()
+		nomes := slices.Sorted(maps.Keys(st.rooms))
+		st.mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(nomes)
+	})
+	mux.HandleFunc("GET /ws", func(w http.ResponseWriter, r *http.Request) {
+		nome := r.URL.Query().Get("sala")
+		if nome == "" {
+			nome = salaPadrao
+		}
+		st.mu.Lock()
+		_, existe := st.rooms[nome]
+		st.mu.Unlock()
+		if !existe {
+			http.Error(w, shared.ErrRoomNotFound.Error(), http.StatusNotFound)
+			return
+		}
+
+		conn, rw, err := aceitarWebSocket(w, r)
+		if err != nil {
+			logDebug("[Espectador] Handshake recusado de %s: %v", r.RemoteAddr, err)
+			return
+		}
+		defer conn.Close()
+		logInfo("[Espectador] %s assistindo a sala %s", r.RemoteAddr, nome)
+		st.transmitirSala(conn, rw, nome)
+		logInfo("[Espectador] %s saiu", r.RemoteAddr)
+	})
+
+	logInfo("[Espectador] Servindo em http://%s/", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		logErro("[Espectador] Erro no servidor HTTP: %v", err)
+	}
+}
+
+// transmitirSala manda um quadro da sala a cada mudança até o navegador fechar
+func (st *ServerState) transmitirSala(conn net.Conn, rw *bufio.ReadWriter, nome string) {
+	var escrita sync.Mutex // O leitor responde pings e closes
+	escrever := func(opcode byte, payload []byte) error {
+		escrita.Lock()
+		defer escrita.Unlock()
+		// Um navegador travado não pode segurar esta goroutine para sempre
+		conn.SetWriteDeadline(time.Now().Add(prazoEscritaEspectador))
+		return escreverQuadroWS(rw.Writer, opcode, payload)
+	}
+	fechou := make(chan struct{})
+	go func() {
+		defer close(fechou)
+		for {
+			op, payload, err := lerQuadroWS(rw.Reader)
+			if err != nil {
+				return
+			}
+			switch op {
+			case wsClose:
+				escrever(wsClose, payload)
+				return
+			case wsPing:
+				escrever(wsPong, payload)
+			}
+		}
+	}()
+
+	mapaEnviado := -1
+	for {
+		st.mu.Lock()
+		room, ok := st.rooms[nome]
+		if !ok {
+			st.mu.Unlock()
+			return
+		}
+		q := room.quadro(room.mapaVersao != mapaEnviado)
+		mapaEnviado = room.mapaVersao
+		changed := room.changed
+		st.mu.Unlock()
+
+		dados, err := json.Marshal(q)
+		if err != nil {
+			logErro("[Espectador] Erro ao codificar quadro: %v", err)
+			return
+		}
+		if err := escrever(wsTexto, dados); err != nil {
+			return
+		}
+
+		select {
+		case <-changed:
+		case <-fechou:
+			return
+		}
+		// Junta as mudanças que chegarem em seguida num quadro só
+		time.Sleep(intervaloEspectador)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"jogo/shared"
+	"time"
+)
+
+// Tempo máximo que WaitForState segura a chamada sem mudanças
+const waitStateTimeout = 10 * time.Second
+
+// bump marca que o conjunto de jogadores da sala mudou e acorda quem espera.
+// Deve ser chamada com mu travado.
+func (room *Room) bump() {
+	room.version++
+	close(room.changed)
+	room.changed = make(chan struct{})
+}
+
+// WaitForState bloqueia até a versão do estado passar de SinceVersion ou expirar.
+// Se SinceVersion está à frente da sala, retorna na hora com o estado completo.
+func (s *GameService) WaitForState(args *shared.WaitForStateArgs, reply *shared.WaitForStateReply) error {
+	s.state.mu.Lock()
+	_, room, err := s.state.salaDaSessao(args.SessionToken)
+	if err != nil {
+		s.state.mu.Unlock()
+		return err
+	}
+	changed := room.changed
+	version := room.version
+	s.state.mu.Unlock()
+
+	// Só espera se o cliente já está atualizado. Uma versão à frente da sala
+	// é de um servidor anterior: volta já, com o estado completo
+	if version == args.SinceVersion {
+		timer := time.NewTimer(waitStateTimeout)
+		defer timer.Stop()
+		select {
+		case <-changed:
+		case <-timer.C:
+		}
+	}
+
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	// O jogador pode ter saído enquanto esperávamos
+	if _, _, err := s.state.salaDaSessao(args.SessionToken); err != nil {
+		return err
+	}
+	reply.StateDelta = room.deltaSince(args.SinceVersion)
+	return nil
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"jogo/shared"
+	"testing"
+	"time"
+)
+
+// Um cliente que conhece uma versão à frente da sala (de antes de o servidor
+// reiniciar) não pode ficar preso esperando o timeout
+func TestWaitForStateVersaoAFrente(t *testing.T) {
+	st := novoServerState(configPadrao(), salaTeste(t, "▤▤▤▤▤", "▤   ▤", "▤▤▤▤▤"), nil)
+	jogadorTeste(t, st, "abc", 1, 1)
+	servico := &GameService{state: st}
+
+	inicio := time.Now()
+	reply := &shared.WaitForStateReply{}
+	if err := servico.WaitForState(&shared.WaitForStateArgs{SessionToken: "abc", SinceVersion: 1000}, reply); err != nil {
+		t.Fatal(err)
+	}
+	if espera := time.Since(inicio); espera > time.Second {
+		t.Errorf("WaitForState esperou %v com a versão à frente", espera)
+	}
+	if !reply.Full || len(reply.AllPlayers) != 1 {
+		t.Errorf("delta = %+v, esperava o estado completo", reply.StateDelta)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"jogo/shared"
+	"time"
+)
+
+// Heartbeat apenas registra que o jogador continua vivo
+func (s *GameService) Heartbeat(args *shared.HeartbeatArgs, reply *shared.HeartbeatReply) error {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	reply.Known = s.state.touch(s.state.jogadorDa(args.SessionToken))
+	return nil
+}
+
+// touch atualiza o último sinal de vida do jogador.
+// Retorna false se o jogador não existe. Deve ser chamada com mu travado.
+func (st *ServerState) touch(id int) bool {
+	if _, ok := st.playerRoom[id]; !ok {
+		return false
+	}
+	st.lastSeen[id] = time.Now()
+	return true
+}
+
+// removePlayer tira o jogador do estado. Deve ser chamada com mu travado.
+func (st *ServerState) removePlayer(id int, motivo string) {
+	room, ok := st.playerRoom[id]
+	if !ok {
+		return
+	}
+	delete(room.players, id)
+	delete(room.lastSeqNums, id)
+	delete(room.ultimoDano, id)
+	delete(room.mortos, id)
+	// A vida em andamento conta para o placar
+	if desde, vivo := room.vivoDesde[id]; vivo {
+		st.ranking.sobreviveu(st.contaDe[id], time.Since(desde))
+		delete(room.vivoDesde, id)
+	}
+	delete(st.playerRoom, id)
+	delete(st.lastSeen, id)
+	delete(st.enderecos, id)
+	delete(st.contaDe, id)
+	delete(st.buckets, id)
+	for token, dono := range st.sessions {
+		if dono == id {
+			delete(st.sessions, token)
+		}
+	}
+	room.recordChange(id, changeLeft)
+	logInfo("[Saída] ID %d removido da sala %s: %s", id, room.nome, motivo)
+}
+
+// reaper remove periodicamente os jogadores sem sinal de vida há mais de timeout
+func (st *ServerState) reaper(timeout time.Duration) {
+	ticker := time.NewTicker(timeout / 2)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		st.mu.Lock()
+		for id, visto := range st.lastSeen {
+			if silencio := now.Sub(visto); silencio > timeout {
+				st.registrar(journalEntry{
+					Op:       opReap,
+					PlayerID: id,
+					Reason:   "sem sinal de vida há " + silencio.Round(time.Second).String(),
+				})
+			}
+		}
+		st.mu.Unlock()
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"jogo/shared"
+	"math/rand"
+	"slices"
+	"time"
+)
+
+// Parâmetros da IA dos inimigos
+type configInimigos struct {
+	passosPorSegundo float64 // Velocidade dos inimigos
+	alcance          int     // Distância (em passos) a partir da qual perseguem um jogador
+}
+
+// inimigoManager move os inimigos de cada sala no ritmo configurado
+func (st *ServerState) inimigoManager(cfg configInimigos) {
+	ticker := time.NewTicker(time.Duration(float64(time.Second) / cfg.passosPorSegundo))
+	defer ticker.Stop()
+
+	for range ticker.C {
+		st.mu.Lock()
+		for nome, room := range st.rooms {
+			if len(room.inimigos) == 0 {
+				continue
+			}
+			// Só registra se alguém se mexeu
+			if novos := room.passoInimigos(cfg.alcance); !slices.Equal(novos, room.inimigos) {
+				st.registrarSimulacao(journalEntry{Op: opEnemies, Room: nome, Enemies: novos})
+			}
+			st.danoPorInimigos(room)
+		}
+		st.mu.Unlock()
+	}
+}
+
+// noInimigo verifica se há um inimigo em (x, y). Deve ser chamada com mu travado.
+func (room *Room) noInimigo(x, y int) bool {
+	for _, e := range room.inimigos {
+		if e.X == x && e.Y == y {
+			return true
+		}
+	}
+	return false
+}
+
+// moverInimigos aplica as novas posições. Deve ser chamada com mu travado.
+func (room *Room) moverInimigos(novos []shared.EnemyState) {
+	room.inimigos = novos
+	room.recordChange(0, changeWorld)
+}
+
+// passoInimigos calcula o próximo estado de todos os inimigos da sala:
+// quem tem um jogador ao alcance persegue, os outros patrulham.
+// Deve ser chamada com mu travado.
+func (room *Room) passoInimigos(alcance int) []shared.EnemyState {
+	novos := make([]shared.EnemyState, len(room.inimigos))
+	copy(novos, room.inimigos)
+
+	// Jogadores mortos não atraem inimigos
+	alvos := make(map[Posicao]bool, len(room.players))
+	for _, p := range room.players {
+		if !p.Dead {
+			alvos[Posicao{p.PosX, p.PosY}] = true
+		}
+	}
+
+	for i, e := range novos {
+		// Os inimigos já movidos neste passo e os que ainda vão mover são obstáculos
+		livre := func(p Posicao) bool {
+			if !room.passavelInimigo(p) || alvos[p] {
+				return false
+			}
+			for j, outro := range novos {
+				if j != i && outro.X == p.X && outro.Y == p.Y {
+					return false
+				}
+			}
+			return true
+		}
+
+		origem := Posicao{e.X, e.Y}
+		if passo, ok := perseguir(origem, alvos, alcance, livre); ok {
+			novos[i].X, novos[i].Y = passo.X, passo.Y
+			novos[i].Chasing = true
+			continue
+		}
+
+		novos[i].Chasing = false
+		passo, dir := patrulhar(origem, Posicao{e.DirX, e.DirY}, livre)
+		novos[i].X, novos[i].Y = passo.X, passo.Y
+		novos[i].DirX, novos[i].DirY = dir.X, dir.Y
+	}
+	return novos
+}
+
+// passavelInimigo diz se um inimigo pode pisar em p: não atravessa paredes,
+// vegetação nem o pato. Deve ser chamada com mu travado.
+func (room *Room) passavelInimigo(p Posicao) bool {
+	if !room.mapa.dentro(p.X, p.Y) || room.mapa.tangivel(p.X, p.Y) || room.noPato(p.X, p.Y) {
+		return false
+	}
+	return room.mapa.Celulas[p.Y][p.X] != SimboloVegetacao
+}
+
+// perseguir faz uma busca em largura a partir de origem, até alcance passos,
+// pelo alvo mais próximo. Retorna a primeira célula do caminho até ele.
+// Se já está ao lado de um alvo, fica parado (e ainda conta como perseguindo).
+func perseguir(origem Posicao, alvos map[Posicao]bool, alcance int, livre func(Posicao) bool) (Posicao, bool) {
+	if len(alvos) == 0 || alcance <= 0 {
+		return Posicao{}, false
+	}
+
+	// primeiro guarda, para cada célula visitada, o primeiro passo que levou até ela
+	primeiro := map[Posicao]Posicao{origem: origem}
+	fila := []Posicao{origem}
+	for dist := 0; len(fila) > 0 && dist < alcance; dist++ {
+		var proxima []Posicao
+		for _, p := range fila {
+			for _, d := range vizinhos {
+				v := Posicao{p.X + d.X, p.Y + d.Y}
+				if _, visto := primeiro[v]; visto {
+					continue
+				}
+				passo := primeiro[p]
+				if p == origem {
+					passo = v
+				}
+				if alvos[v] {
+					if p == origem {
+						return origem, true // Já está ao lado do alvo
+					}
+					return passo, true
+				}
+				if !livre(v) {
+					continue
+				}
+				primeiro[v] = passo
+				proxima = append(proxima, v)
+			}
+		}
+		fila = proxima
+	}
+	return Posicao{}, false
+}
+
+// patrulhar anda em linha reta na direção atual e, quando bate em algo,
+// sorteia uma nova direção livre. Sem saída, fica parado.
+func patrulhar(origem, dir Posicao, livre func(Posicao) bool) (Posicao, Posicao) {
+	if dir != (Posicao{}) {
+		if v := (Posicao{origem.X + dir.X, origem.Y + dir.Y}); livre(v) {
+			return v, dir
+		}
+	}
+
+	var opcoes []Posicao
+	for _, d := range vizinhos {
+		if livre(Posicao{origem.X + d.X, origem.Y + d.Y}) {
+			opcoes = append(opcoes, d)
+		}
+	}
+	if len(opcoes) == 0 {
+		return origem, Posicao{}
+	}
+	d := opcoes[rand.Intn(len(opcoes))]
+	return Posicao{origem.X + d.X, origem.Y + d.Y}, d
+}
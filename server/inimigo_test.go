@@ -0,0 +1,275 @@
+package main
+
+import (
+	"jogo/shared"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// salaTeste monta uma sala a partir de um mapa desenhado no próprio teste.
+// 'J' marca um jogador vivo e 'M' um morto, com IDs 1, 2... na ordem de leitura.
+func salaTeste(t *testing.T, desenho ...string) *Room {
+	t.Helper()
+	linhas := make([]string, len(desenho))
+	var jogadores []shared.PlayerState
+	for y, l := range desenho {
+		runas := []rune(l)
+		for x, ch := range runas {
+			if ch == 'J' || ch == 'M' {
+				jogadores = append(jogadores, shared.PlayerState{PosX: x, PosY: y, HP: shared.MaxHP, Dead: ch == 'M'})
+				runas[x] = SimboloVazio
+			}
+		}
+		linhas[y] = string(runas)
+	}
+
+	arq := filepath.Join(t.TempDir(), "mapa.txt")
+	if err := os.WriteFile(arq, []byte(strings.Join(linhas, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	room, err := novaSala(salaPadrao, arq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range jogadores {
+		room.players[i+1] = p
+	}
+	return room
+}
+
+// alvosDe são as posições dos jogadores vivos, como em passoInimigos
+func alvosDe(room *Room) map[Posicao]bool {
+	alvos := make(map[Posicao]bool)
+	for _, p := range room.players {
+		if !p.Dead {
+			alvos[Posicao{p.PosX, p.PosY}] = true
+		}
+	}
+	return alvos
+}
+
+func TestPerseguir(t *testing.T) {
+	casos := []struct {
+		nome     string
+		mapa     []string
+		alcance  int
+		passo    Posicao
+		persegue bool
+	}{
+		{
+			nome:     "campo aberto",
+			mapa:     []string{"▤▤▤▤▤▤▤▤", "▤☠    J▤", "▤▤▤▤▤▤▤▤"},
+			alcance:  5,
+			passo:    Posicao{2, 1},
+			persegue: true,
+		},
+		{
+			nome:    "fora do alcance",
+			mapa:    []string{"▤▤▤▤▤▤▤▤", "▤☠    J▤", "▤▤▤▤▤▤▤▤"},
+			alcance: 4,
+		},
+		{
+			nome: "contorna a parede",
+			mapa: []string{
+				"▤▤▤▤▤▤▤",
+				"▤☠▤ J ▤",
+				"▤ ▤   ▤",
+				"▤     ▤",
+				"▤▤▤▤▤▤▤",
+			},
+			alcance:  7,
+			passo:    Posicao{1, 2},
+			persegue: true,
+		},
+		{
+			// Em linha reta estaria a 3 passos, mas o caminho de verdade tem 7
+			nome: "parede deixa o alvo fora do alcance",
+			mapa: []string{
+				"▤▤▤▤▤▤▤",
+				"▤☠▤ J ▤",
+				"▤ ▤   ▤",
+				"▤     ▤",
+				"▤▤▤▤▤▤▤",
+			},
+			alcance: 6,
+		},
+		{
+			nome:     "vegetação bloqueia",
+			mapa:     []string{"▤▤▤▤▤", "▤☠♣J▤", "▤   ▤", "▤▤▤▤▤"},
+			alcance:  5,
+			passo:    Posicao{1, 2},
+			persegue: true,
+		},
+		{
+			nome:    "vegetação fecha o caminho",
+			mapa:    []string{"▤▤▤▤▤", "▤☠♣J▤", "▤▤▤▤▤"},
+			alcance: 10,
+		},
+		{
+			nome:     "para ao lado do alvo",
+			mapa:     []string{"▤▤▤▤▤", "▤☠J ▤", "▤▤▤▤▤"},
+			alcance:  5,
+			passo:    Posicao{1, 1},
+			persegue: true,
+		},
+		{
+			nome:    "sem alvos",
+			mapa:    []string{"▤▤▤▤▤", "▤☠  ▤", "▤▤▤▤▤"},
+			alcance: 5,
+		},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			room := salaTeste(t, c.mapa...)
+			alvos := alvosDe(room)
+			livre := func(p Posicao) bool { return room.passavelInimigo(p) && !alvos[p] }
+			origem := Posicao{room.inimigos[0].X, room.inimigos[0].Y}
+
+			passo, persegue := perseguir(origem, alvos, c.alcance, livre)
+			if persegue != c.persegue {
+				t.Fatalf("persegue = %v, esperava %v", persegue, c.persegue)
+			}
+			if persegue && passo != c.passo {
+				t.Errorf("passo = %v, esperava %v", passo, c.passo)
+			}
+		})
+	}
+}
+
+func TestPatrulhar(t *testing.T) {
+	casos := []struct {
+		nome    string
+		mapa    []string
+		dir     Posicao
+		passo   Posicao
+		novaDir Posicao
+	}{
+		{
+			nome:    "segue em frente",
+			mapa:    []string{"▤▤▤▤▤", "▤☠  ▤", "▤▤▤▤▤"},
+			dir:     Posicao{1, 0},
+			passo:   Posicao{2, 1},
+			novaDir: Posicao{1, 0},
+		},
+		{
+			nome:    "vira no corredor",
+			mapa:    []string{"▤▤▤", "▤☠▤", "▤ ▤", "▤▤▤"},
+			dir:     Posicao{1, 0},
+			passo:   Posicao{1, 2},
+			novaDir: Posicao{0, 1},
+		},
+		{
+			nome:    "vegetação conta como parede",
+			mapa:    []string{"▤▤▤▤", "▤☠♣▤", "▤ ▤▤", "▤▤▤▤"},
+			dir:     Posicao{1, 0},
+			passo:   Posicao{1, 2},
+			novaDir: Posicao{0, 1},
+		},
+		{
+			nome:  "sem saída fica parado",
+			mapa:  []string{"▤▤▤", "▤☠▤", "▤▤▤"},
+			dir:   Posicao{1, 0},
+			passo: Posicao{1, 1},
+		},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			room := salaTeste(t, c.mapa...)
+			origem := Posicao{room.inimigos[0].X, room.inimigos[0].Y}
+
+			passo, dir := patrulhar(origem, c.dir, room.passavelInimigo)
+			if passo != c.passo || dir != c.novaDir {
+				t.Errorf("patrulhar = %v, %v; esperava %v, %v", passo, dir, c.passo, c.novaDir)
+			}
+		})
+	}
+}
+
+func TestPassoInimigos(t *testing.T) {
+	casos := []struct {
+		nome     string
+		mapa     []string
+		alcance  int
+		esperado []shared.EnemyState
+	}{
+		{
+			// O de trás está bloqueado pelo da frente e não pode ocupar a mesma célula
+			nome:    "dois inimigos no mesmo corredor",
+			mapa:    []string{"▤▤▤▤▤▤", "▤☠☠ J▤", "▤▤▤▤▤▤"},
+			alcance: 5,
+			esperado: []shared.EnemyState{
+				{X: 1, Y: 1},
+				{X: 3, Y: 1, Chasing: true},
+			},
+		},
+		{
+			nome:     "para ao lado do jogador",
+			mapa:     []string{"▤▤▤▤▤", "▤ ☠J▤", "▤▤▤▤▤"},
+			alcance:  5,
+			esperado: []shared.EnemyState{{X: 2, Y: 1, Chasing: true}},
+		},
+		{
+			nome:     "jogador morto não atrai",
+			mapa:     []string{"▤▤▤▤▤", "▤☠ M▤", "▤▤▤▤▤"},
+			alcance:  5,
+			esperado: []shared.EnemyState{{X: 2, Y: 1, DirX: 1}},
+		},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			room := salaTeste(t, c.mapa...)
+			novos := room.passoInimigos(c.alcance)
+			if len(novos) != len(c.esperado) {
+				t.Fatalf("%d inimigos, esperava %d", len(novos), len(c.esperado))
+			}
+			for i := range novos {
+				if novos[i] != c.esperado[i] {
+					t.Errorf("inimigo %d = %+v, esperava %+v", i, novos[i], c.esperado[i])
+				}
+			}
+		})
+	}
+}
+
+// Vários passos numa arena: inimigos nunca dividem célula, nunca pisam em
+// jogadores, paredes ou vegetação, e andam no máximo uma célula por vez
+func TestPassoInimigosSemColisao(t *testing.T) {
+	room := salaTeste(t,
+		"▤▤▤▤▤▤▤▤▤▤",
+		"▤☠   ♣  ☠▤",
+		"▤  ▤▤    ▤",
+		"▤   J  ♣ ▤",
+		"▤☠  ▤   ☠▤",
+		"▤▤▤▤▤▤▤▤▤▤",
+	)
+	jogador := Posicao{room.players[1].PosX, room.players[1].PosY}
+
+	for passo := range 30 {
+		antes := room.inimigos
+		novos := room.passoInimigos(4)
+		ocupadas := make(map[Posicao]bool)
+		for i, e := range novos {
+			p := Posicao{e.X, e.Y}
+			if ocupadas[p] {
+				t.Fatalf("passo %d: dois inimigos em %v", passo, p)
+			}
+			ocupadas[p] = true
+			if p == jogador {
+				t.Fatalf("passo %d: inimigo %d em cima do jogador", passo, i)
+			}
+			if !room.passavelInimigo(p) {
+				t.Fatalf("passo %d: inimigo %d em célula bloqueada %v", passo, i, p)
+			}
+			dx, dy := e.X-antes[i].X, e.Y-antes[i].Y
+			if dx*dx+dy*dy > 1 {
+				t.Fatalf("passo %d: inimigo %d saltou de %v para %v", passo, i, antes[i], p)
+			}
+		}
+		room.moverInimigos(novos)
+	}
+}
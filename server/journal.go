@@ -0,0 +1,348 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"jogo/shared"
+	"os"
+	"time"
+)
+
+// Operações registradas no journal
+const (
+	opConnect     = "connect"
+	opUpdate      = "update"
+	opDisconnect  = "disconnect"
+	opReap        = "reap"
+	opCreateRoom  = "create_room"
+	opCoin        = "coin"
+	opCoinClear   = "coin_clear"
+	opPortalOpen  = "portal_open"
+	opPortalClose = "portal_close"
+	opDuckMove    = "duck_move"
+	opDuckPet     = "duck_pet"
+	opEnemies     = "enemies"
+	opDamage      = "damage"
+	opRespawn     = "respawn"
+	opKick        = "kick"
+	opTeleport    = "teleport"
+	opReloadMap   = "reload_map"
+)
+
+// journalEntry é um comando aceito que altera o ServerState.
+// Reaplicar as entradas em ordem reconstrói o mesmo estado.
+type journalEntry struct {
+	Index    int64               `json:"index"`
+	Op       string              `json:"op"`
+	PlayerID int                 `json:"player_id"`
+	Seq      int                 `json:"seq,omitempty"`
+	X        int                 `json:"x,omitempty"`
+	Y        int                 `json:"y,omitempty"`
+	Accepted bool                `json:"accepted,omitempty"` // Só para update: se a posição mudou
+	Reason   string              `json:"reason,omitempty"`
+	Token    string              `json:"token,omitempty"`   // Só para connect
+	Account  string              `json:"account,omitempty"` // Só para connect
+	Room     string              `json:"room,omitempty"`    // Para connect e operações da sala
+	Map      string              `json:"map,omitempty"`     // Só para create_room
+	Lines    []string            `json:"lines,omitempty"`   // Só para reload_map: o conteúdo lido do arquivo
+	Enemies  []shared.EnemyState `json:"enemies,omitempty"` // Só para enemies
+}
+
+// Journal é o log append-only dos comandos aceitos, um JSON por linha.
+// A cada snapshot as entradas vão para o arquivo .anterior, apagado quando
+// o snapshot que as contém chega ao disco; assim o journal não cresce sem fim.
+type Journal struct {
+	path string
+	arq  *os.File
+	enc  *json.Encoder
+}
+
+// arquivoAnterior é onde ficam as entradas de antes do snapshot em andamento
+func arquivoAnterior(path string) string {
+	return path + ".anterior"
+}
+
+// abrirJournal abre (ou cria) o journal para acrescentar entradas.
+// Só o dono lê: as entradas de connect guardam tokens de sessão.
+func abrirJournal(path string) (*Journal, error) {
+	arq, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	// Journals criados por versões antigas eram legíveis por todos
+	if err := arq.Chmod(0o600); err != nil {
+		arq.Close()
+		return nil, err
+	}
+	return &Journal{path: path, arq: arq, enc: json.NewEncoder(arq)}, nil
+}
+
+// append grava a entrada no arquivo antes de ela ser aplicada.
+// Com sincronizar, só retorna depois de ela chegar ao disco.
+func (j *Journal) append(e journalEntry, sincronizar bool) error {
+	if err := j.enc.Encode(e); err != nil {
+		return err
+	}
+	if !sincronizar {
+		return nil
+	}
+	return j.arq.Sync()
+}
+
+// rotacionar passa as entradas atuais para o arquivo anterior e recomeça vazio.
+// Se o anterior ainda existe (o último snapshot falhou), acrescenta a ele.
+// Deve ser chamada com mu travado.
+func (j *Journal) rotacionar() error {
+	anterior := arquivoAnterior(j.path)
+	if _, err := os.Stat(anterior); errors.Is(err, fs.ErrNotExist) {
+		if err := j.arq.Close(); err != nil {
+			return err
+		}
+		if err := os.Rename(j.path, anterior); err != nil {
+			return err
+		}
+	} else {
+		if err := acrescentarArquivo(anterior, j.path); err != nil {
+			return err
+		}
+		if err := j.arq.Close(); err != nil {
+			return err
+		}
+		if err := os.Truncate(j.path, 0); err != nil {
+			return err
+		}
+	}
+	novo, err := abrirJournal(j.path)
+	if err != nil {
+		return err
+	}
+	*j = *novo
+	return nil
+}
+
+// acrescentarArquivo copia o conteúdo de origem para o fim de destino, com fsync
+func acrescentarArquivo(destino, origem string) error {
+	dados, err := os.ReadFile(origem)
+	if err != nil {
+		return err
+	}
+	arq, err := os.OpenFile(destino, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := arq.Write(dados); err != nil {
+		arq.Close()
+		return err
+	}
+	if err := arq.Sync(); err != nil {
+		arq.Close()
+		return err
+	}
+	return arq.Close()
+}
+
+// descartarAnterior apaga as entradas que o snapshot recém-gravado já contém
+func (j *Journal) descartarAnterior() error {
+	err := os.Remove(arquivoAnterior(j.path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// registrar grava a entrada no journal, com fsync, e a aplica no estado.
+// É o caminho dos comandos dos jogadores e do administrador.
+// Se a gravação falhar o comando não é aplicado. Deve ser chamada com mu travado.
+func (st *ServerState) registrar(e journalEntry) error {
+	return st.gravarEAplicar(e, true)
+}
+
+// registrarSimulacao é o registrar dos managers (inimigos, pato, moeda...).
+// Não faz fsync a cada passo: numa queda do sistema só se perdem os últimos
+// passos da simulação, e o próximo comando sincroniza o arquivo inteiro.
+// Deve ser chamada com mu travado.
+func (st *ServerState) registrarSimulacao(e journalEntry) {
+	st.gravarEAplicar(e, false)
+}
+
+// gravarEAplicar numera a entrada, grava no journal se houver e a aplica.
+// Deve ser chamada com mu travado.
+func (st *ServerState) gravarEAplicar(e journalEntry, sincronizar bool) error {
+	e.Index = st.journalIndex + 1
+	if st.journal != nil {
+		if err := st.journal.append(e, sincronizar); err != nil {
+			logErro("[Journal] Erro ao gravar %s do ID %d: %v", e.Op, e.PlayerID, err)
+			return err
+		}
+	}
+	st.aplicar(e)
+	return nil
+}
+
+// aplicar executa a entrada sobre o estado, sem validar nada.
+// Deve ser chamada com mu travado.
+func (st *ServerState) aplicar(e journalEntry) {
+	st.journalIndex = e.Index
+
+	switch e.Op {
+	case opCreateRoom:
+		room, err := novaSala(e.Room, e.Map)
+		if err != nil {
+			logErro("[Sala] Erro ao criar %s com o mapa %s: %v", e.Room, e.Map, err)
+			return
+		}
+		st.rooms[e.Room] = room
+
+	case opConnect:
+		room, ok := st.rooms[e.Room]
+		if !ok {
+			logAviso("[Journal] Connect do ID %d em sala inexistente %s", e.PlayerID, e.Room)
+			return
+		}
+		room.players[e.PlayerID] = shared.PlayerState{PosX: e.X, PosY: e.Y, HP: shared.MaxHP}
+		room.lastSeqNums[e.PlayerID] = 0
+		room.vivoDesde[e.PlayerID] = time.Now()
+		st.playerRoom[e.PlayerID] = room
+		st.lastSeen[e.PlayerID] = time.Now()
+		st.sessions[e.Token] = e.PlayerID
+		st.contaDe[e.PlayerID] = e.Account
+		if e.PlayerID >= st.nextID {
+			st.nextID = e.PlayerID + 1
+		}
+		room.recordChange(e.PlayerID, changeJoined)
+
+	case opUpdate:
+		room, ok := st.playerRoom[e.PlayerID]
+		if !ok {
+			return
+		}
+		room.lastSeqNums[e.PlayerID] = e.Seq
+		if e.Accepted {
+			p := room.players[e.PlayerID]
+			p.PosX, p.PosY = e.X, e.Y
+			room.players[e.PlayerID] = p
+			room.recordChange(e.PlayerID, changeMoved)
+			room.coletarMoeda(e.PlayerID)
+		}
+
+	case opCoin:
+		if room, ok := st.rooms[e.Room]; ok {
+			room.colocarMoeda(e.X, e.Y)
+		}
+
+	case opCoinClear:
+		if room, ok := st.rooms[e.Room]; ok {
+			room.tirarMoeda()
+		}
+
+	case opPortalOpen:
+		if room, ok := st.rooms[e.Room]; ok {
+			room.abrirPortal(e.X, e.Y)
+		}
+
+	case opPortalClose:
+		if room, ok := st.rooms[e.Room]; ok {
+			room.fecharPortal(e.PlayerID)
+		}
+
+	case opDuckMove:
+		if room, ok := st.rooms[e.Room]; ok {
+			room.moverPato(e.X, e.Y)
+		}
+
+	case opDuckPet:
+		if room, ok := st.rooms[e.Room]; ok {
+			room.pararPato(e.PlayerID)
+		}
+
+	case opEnemies:
+		if room, ok := st.rooms[e.Room]; ok {
+			room.moverInimigos(e.Enemies)
+		}
+
+	case opDamage:
+		if room, ok := st.rooms[e.Room]; ok {
+			room.ferir(e.PlayerID)
+		}
+
+	case opRespawn:
+		if room, ok := st.rooms[e.Room]; ok {
+			room.renascer(e.PlayerID, e.X, e.Y)
+		}
+
+	case opTeleport:
+		if room, ok := st.rooms[e.Room]; ok {
+			room.teletransportar(e.PlayerID, e.X, e.Y)
+		}
+
+	case opReloadMap:
+		if room, ok := st.rooms[e.Room]; ok {
+			linhas := e.Lines
+			if linhas == nil {
+				// Journals antigos não guardavam o conteúdo; o arquivo é o que resta
+				mapa, err := carregarMapa(room.mapaArquivo)
+				if err != nil {
+					logErro("[Sala] Erro ao recarregar o mapa de %s: %v", e.Room, err)
+					return
+				}
+				linhas = mapa.Linhas
+			}
+			if err := room.recarregarMapa(linhas); err != nil {
+				logErro("[Sala] Erro ao recarregar o mapa de %s: %v", e.Room, err)
+			}
+		}
+
+	case opDisconnect, opReap, opKick:
+		st.removePlayer(e.PlayerID, e.Reason)
+	}
+}
+
+// replayJournal reaplica as entradas do journal em path, começando pelas que
+// ficaram no arquivo anterior, com índice maior que o atual.
+// Com ate > 0, para depois da entrada de índice ate. Deve ser chamada com mu travado.
+func (st *ServerState) replayJournal(path string, ate int64) (int, error) {
+	total := 0
+	for _, arquivo := range []string{arquivoAnterior(path), path} {
+		n, err := st.replayArquivo(arquivo, ate)
+		total += n
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// replayArquivo reaplica as entradas de um arquivo do journal.
+// Deve ser chamada com mu travado.
+func (st *ServerState) replayArquivo(path string, ate int64) (int, error) {
+	arq, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer arq.Close()
+
+	aplicadas := 0
+	scanner := bufio.NewScanner(arq)
+	for linha := 1; scanner.Scan(); linha++ {
+		var e journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// Normalmente a última linha, cortada por uma queda no meio da escrita
+			logAviso("[Journal] Linha %d de %s ilegível, parando: %v", linha, path, err)
+			break
+		}
+		if ate > 0 && e.Index > ate {
+			break
+		}
+		if e.Index <= st.journalIndex {
+			continue // Já está no snapshot
+		}
+		st.aplicar(e)
+		aplicadas++
+	}
+	return aplicadas, scanner.Err()
+}
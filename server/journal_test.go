@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// restaurarTeste sobe um estado novo como o main faz ao reiniciar:
+// sala do arquivo de mapa, snapshot e depois o journal
+func restaurarTeste(t *testing.T, arqMapa, estado, journal string) *ServerState {
+	t.Helper()
+	room, err := novaSala(salaPadrao, arqMapa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := novoServerState(configPadrao(), room, contasTeste(t))
+	if err := st.carregarEstado(estado); err != nil {
+		t.Fatal(err)
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if _, err := st.replayJournal(journal, 0); err != nil {
+		t.Fatal(err)
+	}
+	return st
+}
+
+// conferirRestaurado compara o estado restaurado com o original
+func conferirRestaurado(t *testing.T, original, restaurado *ServerState) {
+	t.Helper()
+	a, b := original.rooms[salaPadrao], restaurado.rooms[salaPadrao]
+	if b.players[1] != a.players[1] {
+		t.Errorf("jogador restaurado = %+v, esperava %+v", b.players[1], a.players[1])
+	}
+	if b.lastSeqNums[1] != a.lastSeqNums[1] {
+		t.Errorf("sequência restaurada = %d, esperava %d", b.lastSeqNums[1], a.lastSeqNums[1])
+	}
+	if !slices.Equal(b.mapa.Linhas, a.mapa.Linhas) {
+		t.Errorf("mapa restaurado = %q, esperava %q", b.mapa.Linhas, a.mapa.Linhas)
+	}
+	if restaurado.journalIndex != original.journalIndex {
+		t.Errorf("índice restaurado = %d, esperava %d", restaurado.journalIndex, original.journalIndex)
+	}
+}
+
+// O snapshot, o journal anterior e o atual juntos reconstroem o estado,
+// mesmo com o arquivo do mapa trocado depois de um reload-map
+func TestReplayDepoisDaRotacao(t *testing.T) {
+	dir := t.TempDir()
+	estado := filepath.Join(dir, "estado.json")
+	journal := filepath.Join(dir, "journal.log")
+
+	original := []string{"▤▤▤▤▤▤", "▤☺   ▤", "▤▤▤▤▤▤"}
+	arqMapa := filepath.Join(dir, "mapa.txt")
+	if err := os.WriteFile(arqMapa, []byte(strings.Join(original, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	room, err := novaSala(salaPadrao, arqMapa)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := novoServerState(configPadrao(), room, contasTeste(t))
+	if st.journal, err = abrirJournal(journal); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { st.journal.arq.Close() })
+
+	mover := func(seq, x int) {
+		t.Helper()
+		e := journalEntry{Op: opUpdate, PlayerID: 1, Seq: seq, X: x, Y: 1, Accepted: true}
+		if err := st.registrar(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.registrar(journalEntry{Op: opConnect, PlayerID: 1, Room: salaPadrao, Token: "abc", Account: "ana", X: 1, Y: 1}); err != nil {
+		t.Fatal(err)
+	}
+	mover(1, 2)
+	if err := st.salvarEstado(estado); err != nil {
+		t.Fatal(err)
+	}
+
+	// Um reload-map com uma parede nova, e um snapshot que não terminou:
+	// as entradas ficam no arquivo anterior
+	recarregado := []string{"▤▤▤▤▤▤", "▤☺  ▤▤", "▤▤▤▤▤▤"}
+	if err := os.WriteFile(arqMapa, []byte(strings.Join(recarregado, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if resposta := st.comandoAdmin("reload-map", nil); !strings.Contains(resposta, "recarregado") {
+		t.Fatalf("reload-map: %s", resposta)
+	}
+	mover(2, 3)
+	if err := st.journal.rotacionar(); err != nil {
+		t.Fatal(err)
+	}
+	mover(3, 2)
+
+	// O arquivo muda de novo depois; o replay não pode usá-lo
+	if err := os.WriteFile(arqMapa, []byte(strings.Join(original, "\n")), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	conferirRestaurado(t, st, restaurarTeste(t, arqMapa, estado, journal))
+
+	// Com o reload-map já dentro do snapshot, vale o mapa guardado nele
+	if err := st.salvarEstado(estado); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(arquivoAnterior(journal)); !os.IsNotExist(err) {
+		t.Errorf("journal anterior continua no disco depois do snapshot: %v", err)
+	}
+	conferirRestaurado(t, st, restaurarTeste(t, arqMapa, estado, journal))
+	if !slices.Equal(st.rooms[salaPadrao].mapa.Linhas, recarregado) {
+		t.Errorf("mapa em uso = %q, esperava o recarregado", st.rooms[salaPadrao].mapa.Linhas)
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/rpc/jsonrpc"
+)
+
+// Tamanho máximo do corpo de uma chamada por HTTP
+const maxCorpoRPC = 1 << 20
+
+// corpoHTTP junta o corpo da requisição e a resposta num io.ReadWriteCloser para o codec
+type corpoHTTP struct {
+	io.Reader
+	io.Writer
+}
+
+func (corpoHTTP) Close() error { return nil }
+
+// servirRPCHTTP atende chamadas JSON-RPC 1.0 feitas por POST em /rpc,
+// uma chamada por requisição, no mesmo formato do listener JSON-RPC:
+//
+//	{"method": "GameService.GetState", "params": [{"session_token": "..."}], "id": 1}
+//
+// Com tlsConfig o endpoint só atende HTTPS, como os listeners RPC.
+func (st *ServerState) servirRPCHTTP(addr string, tlsConfig *tls.Config) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /rpc", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		corpo := corpoHTTP{Reader: http.MaxBytesReader(w, r.Body, maxCorpoRPC), Writer: w}
+		codec := medirCodec(jsonrpc.NewServerCodec(corpo), st.metricas)
+		if err := novoServidorRPC(st, hostDe(r.RemoteAddr)).ServeRequest(codec); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		}
+	})
+	srv := &http.Server{Addr: addr, Handler: mux, TLSConfig: tlsConfig}
+	var err error
+	if tlsConfig != nil {
+		logInfo("[JSON] JSON-RPC por HTTPS em https://%s/rpc", addr)
+		err = srv.ListenAndServeTLS("", "") // O certificado já está em tlsConfig
+	} else {
+		logInfo("[JSON] JSON-RPC por HTTP em http://%s/rpc", addr)
+		err = srv.ListenAndServe()
+	}
+	if err != nil {
+		logErro("[JSON] Erro no servidor HTTP: %v", err)
+	}
+}
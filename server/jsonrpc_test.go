@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"net"
+	"net/rpc/jsonrpc"
+	"testing"
+	"time"
+
+	"jogo/shared"
+)
+
+// clienteJSON fala JSON-RPC 1.0 cru, para conferir os nomes no fio
+type clienteJSON struct {
+	t   *testing.T
+	enc *json.Encoder
+	dec *json.Decoder
+	id  int
+}
+
+// chamar faz uma chamada e devolve o result como mapa, ou o texto do erro
+func (c *clienteJSON) chamar(metodo string, params any) (map[string]any, string) {
+	c.t.Helper()
+	c.id++
+	req := map[string]any{"method": "GameService." + metodo, "params": []any{params}, "id": c.id}
+	if err := c.enc.Encode(req); err != nil {
+		c.t.Fatal(err)
+	}
+	var resp struct {
+		ID     int            `json:"id"`
+		Result map[string]any `json:"result"`
+		Error  any            `json:"error"`
+	}
+	if err := c.dec.Decode(&resp); err != nil {
+		c.t.Fatal(err)
+	}
+	if resp.ID != c.id {
+		c.t.Fatalf("%s: resposta com id %d, esperava %d", metodo, resp.ID, c.id)
+	}
+	if resp.Error != nil {
+		erro, _ := resp.Error.(string)
+		return nil, erro
+	}
+	return resp.Result, ""
+}
+
+// exigirCampos falha se algum dos nomes não estiver no objeto
+func exigirCampos(t *testing.T, onde string, obj map[string]any, campos ...string) {
+	t.Helper()
+	for _, c := range campos {
+		if _, ok := obj[c]; !ok {
+			t.Errorf("%s: campo %q ausente em %v", onde, c, obj)
+		}
+	}
+}
+
+// servidorJSONTeste sobe o listener JSON-RPC numa porta livre com uma sala pequena
+func servidorJSONTeste(t *testing.T) string {
+	t.Helper()
+	cfg := configPadrao()
+	contas, err := abrirContas("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := novoServerState(cfg, salaTeste(t, "▤▤▤▤▤", "▤☺  ▤", "▤▤▤▤▤"), contas)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go aceitarConexoes(st, listener, jsonrpc.NewServerCodec)
+	return listener.Addr().String()
+}
+
+func TestCicloJSONRPC(t *testing.T) {
+	addr := servidorJSONTeste(t)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	c := &clienteJSON{t: t, enc: json.NewEncoder(conn), dec: json.NewDecoder(conn)}
+
+	credenciais := map[string]any{"username": "ana", "password": "segredo123"}
+	if _, erro := c.chamar("Register", credenciais); erro != "" {
+		t.Fatalf("Register: %s", erro)
+	}
+
+	connect, erro := c.chamar("Connect", map[string]any{
+		"username": "ana", "password": "segredo123", "protocol": shared.ProtocolVersion,
+	})
+	if erro != "" {
+		t.Fatalf("Connect: %s", erro)
+	}
+	exigirCampos(t, "Connect", connect, "player_id", "session_token", "room", "pos_x", "pos_y", "map_lines", "map_version", "all_players")
+	token, _ := connect["session_token"].(string)
+	if token == "" {
+		t.Fatalf("Connect sem session_token: %v", connect)
+	}
+	if x, y := connect["pos_x"], connect["pos_y"]; x != 1.0 || y != 1.0 {
+		t.Fatalf("nasceu em (%v, %v), esperava (1, 1)", x, y)
+	}
+
+	update, erro := c.chamar("UpdateState", map[string]any{
+		"session_token": token, "new_x": 2, "new_y": 1, "sequence_number": 1,
+	})
+	if erro != "" {
+		t.Fatalf("UpdateState: %s", erro)
+	}
+	exigirCampos(t, "UpdateState", update, "accepted", "reason", "pos_x", "pos_y")
+	if update["accepted"] != true || update["pos_x"] != 2.0 {
+		t.Fatalf("movimento não aceito: %v", update)
+	}
+
+	estado, erro := c.chamar("GetState", map[string]any{"session_token": token})
+	if erro != "" {
+		t.Fatalf("GetState: %s", erro)
+	}
+	exigirCampos(t, "GetState", estado, "version", "all_players")
+	jogadores, _ := estado["all_players"].(map[string]any)
+	if len(jogadores) != 1 {
+		t.Fatalf("esperava um jogador, veio %v", estado["all_players"])
+	}
+	for _, j := range jogadores {
+		jogador, _ := j.(map[string]any)
+		exigirCampos(t, "GetState.all_players", jogador, "pos_x", "pos_y", "score", "hp", "dead", "warps")
+		if jogador["pos_x"] != 2.0 {
+			t.Errorf("posição no estado = %v, esperava x = 2", jogador)
+		}
+	}
+
+	if _, erro := c.chamar("Disconnect", map[string]any{"session_token": token, "sequence_number": 2}); erro != "" {
+		t.Fatalf("Disconnect: %s", erro)
+	}
+	batida, erro := c.chamar("Heartbeat", map[string]any{"session_token": token})
+	if erro != "" {
+		t.Fatalf("Heartbeat: %s", erro)
+	}
+	if batida["known"] != false {
+		t.Errorf("sessão ainda conhecida depois do Disconnect: %v", batida)
+	}
+}
+
+func TestConnectJSONRPCRecusado(t *testing.T) {
+	addr := servidorJSONTeste(t)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	c := &clienteJSON{t: t, enc: json.NewEncoder(conn), dec: json.NewDecoder(conn)}
+
+	_, erro := c.chamar("Connect", map[string]any{"username": "ninguem", "password": "x", "protocol": shared.ProtocolVersion})
+	re := shared.ParseRejectError(errString(erro))
+	if re == nil || re.Reason != shared.RejectBadCredentials {
+		t.Fatalf("erro = %q, esperava recusa por credenciais", erro)
+	}
+}
+
+// errString transforma o texto de erro do fio de volta num error
+type errString string
+
+func (e errString) Error() string { return string(e) }
+
+// Sem sessão válida nenhuma chamada devolve o estado de uma sala nem cria salas
+func TestSessaoInvalidaJSONRPC(t *testing.T) {
+	addr := servidorJSONTeste(t)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+	c := &clienteJSON{t: t, enc: json.NewEncoder(conn), dec: json.NewDecoder(conn)}
+
+	for _, metodo := range []string{"GetState", "GetStateDelta", "WaitForState", "GetChat", "CreateRoom", "GetLeaderboard", "Interact"} {
+		for _, token := range []string{"", "inventado"} {
+			resultado, erro := c.chamar(metodo, map[string]any{"session_token": token})
+			if erro != shared.ErrSessionExpired {
+				t.Errorf("%s com token %q: erro = %q, resultado = %v; esperava %q", metodo, token, erro, resultado, shared.ErrSessionExpired)
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"time"
+)
+
+// Quanto um movimento pode esperar pela vez antes de ser descartado
+const maxEsperaMovimento = 250 * time.Millisecond
+
+// limiteMovimentos controla quantos UpdateState por segundo cada jogador pode mandar
+type limiteMovimentos struct {
+	taxa   float64 // Fichas repostas por segundo, 0 desativa
+	rajada float64 // Máximo de fichas acumuladas
+}
+
+// tokenBucket guarda as fichas de movimento de um jogador
+type tokenBucket struct {
+	fichas      float64
+	ultimo      time.Time // Última reposição
+	descartados int       // Movimentos descartados desde o último aviso no log
+	ultimoAviso time.Time
+}
+
+// reservar consome uma ficha e retorna quanto esperar até ela existir.
+// Se a espera passar de maxEspera, nada é consumido e retorna false.
+func (b *tokenBucket) reservar(agora time.Time, lim limiteMovimentos, maxEspera time.Duration) (time.Duration, bool) {
+	b.fichas = min(lim.rajada, b.fichas+agora.Sub(b.ultimo).Seconds()*lim.taxa)
+	b.ultimo = agora
+
+	var espera time.Duration
+	if b.fichas < 1 {
+		espera = time.Duration((1 - b.fichas) / lim.taxa * float64(time.Second))
+		if espera > maxEspera {
+			return espera, false
+		}
+	}
+	// Pode ficar negativo: as próximas reservas esperam atrás desta
+	b.fichas--
+	return espera, true
+}
+
+// aguardarVez aplica o limite de movimentos do jogador: espera a vez dele
+// ou retorna false se o comando deve ser descartado. Trava mu por conta própria.
+func (st *ServerState) aguardarVez(id int) bool {
+	if st.limite.taxa <= 0 {
+		return true
+	}
+
+	st.mu.Lock()
+	if _, ok := st.playerRoom[id]; !ok {
+		st.mu.Unlock()
+		return true // UpdateState responde que o jogador não existe
+	}
+	agora := time.Now()
+	b, ok := st.buckets[id]
+	if !ok {
+		b = &tokenBucket{fichas: st.limite.rajada, ultimo: agora}
+		st.buckets[id] = b
+	}
+	espera, ok := b.reservar(agora, st.limite, maxEsperaMovimento)
+	if !ok {
+		st.descartados++
+		b.descartados++
+		// Um cliente trapaceando manda centenas por segundo, avisa no máximo uma vez por segundo
+		if agora.Sub(b.ultimoAviso) >= time.Second {
+			logAviso("[Limite] ID %d passou de %.0f movimentos/s, %d descartados", id, st.limite.taxa, b.descartados)
+			b.descartados = 0
+			b.ultimoAviso = agora
+		}
+	}
+	st.mu.Unlock()
+
+	if ok && espera > 0 {
+		time.Sleep(espera)
+	}
+	return ok
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketReservar(t *testing.T) {
+	lim := limiteMovimentos{taxa: 10, rajada: 3} // Uma ficha a cada 100ms
+	agora := time.Now()
+	b := &tokenBucket{fichas: lim.rajada, ultimo: agora}
+
+	// A rajada passa sem esperar
+	for i := range int(lim.rajada) {
+		if espera, ok := b.reservar(agora, lim, maxEsperaMovimento); !ok || espera != 0 {
+			t.Fatalf("reserva %d da rajada = %v, %v; esperava passar na hora", i+1, espera, ok)
+		}
+	}
+
+	// Sem fichas, as próximas esperam na fila até maxEsperaMovimento
+	for _, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond} {
+		espera, ok := b.reservar(agora, lim, maxEsperaMovimento)
+		if !ok || (espera-want).Abs() > time.Millisecond {
+			t.Fatalf("reserva sem fichas = %v, %v; esperava esperar %v", espera, ok, want)
+		}
+	}
+
+	// Além de maxEsperaMovimento é recusada e não consome nada
+	fichas := b.fichas
+	if espera, ok := b.reservar(agora, lim, maxEsperaMovimento); ok || espera <= maxEsperaMovimento {
+		t.Fatalf("reserva além do limite = %v, %v; esperava recusa", espera, ok)
+	}
+	if b.fichas != fichas {
+		t.Errorf("recusa consumiu fichas: %v -> %v", fichas, b.fichas)
+	}
+
+	// Com o tempo as fichas voltam, mas nunca passam da rajada
+	depois := agora.Add(time.Hour)
+	if espera, ok := b.reservar(depois, lim, maxEsperaMovimento); !ok || espera != 0 {
+		t.Fatalf("reserva depois da reposição = %v, %v; esperava passar na hora", espera, ok)
+	}
+	if b.fichas != lim.rajada-1 {
+		t.Errorf("fichas = %v depois de uma hora, esperava a rajada menos uma (%v)", b.fichas, lim.rajada-1)
+	}
+}
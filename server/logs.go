@@ -0,0 +1,44 @@
+package main
+
+import (
+	"log"
+)
+
+// Níveis de log, do mais ao menos detalhado
+type nivelLog int
+
+const (
+	nivelDebug nivelLog = iota
+	nivelInfo
+	nivelAviso
+	nivelErro
+)
+
+// Nomes aceitos em -log-level
+var niveisLog = map[string]nivelLog{
+	"debug": nivelDebug,
+	"info":  nivelInfo,
+	"warn":  nivelAviso,
+	"error": nivelErro,
+}
+
+// Mensagens abaixo deste nível são descartadas
+var nivelMinimo = nivelInfo
+
+func logNivel(nivel nivelLog, format string, args ...any) {
+	if nivel >= nivelMinimo {
+		log.Printf(format, args...)
+	}
+}
+
+// logDebug é para o que acontece a cada comando: movimentos, sequence numbers...
+func logDebug(format string, args ...any) { logNivel(nivelDebug, format, args...) }
+
+// logInfo é para eventos do jogo e do servidor
+func logInfo(format string, args ...any) { logNivel(nivelInfo, format, args...) }
+
+// logAviso é para situações estranhas das quais o servidor se recupera
+func logAviso(format string, args ...any) { logNivel(nivelAviso, format, args...) }
+
+// logErro é para falhas, como não conseguir gravar no disco
+func logErro(format string, args ...any) { logNivel(nivelErro, format, args...) }
@@ -1,89 +1,319 @@
 package main
 
 import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"jogo/shared"
 	"log"
 	"maps"
 	"net"
 	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"os/signal"
+	"slices"
 	"sync"
+	"syscall"
+	"time"
 )
 
 // ServerState é o único estado do servidor
 type ServerState struct {
-	mu          sync.Mutex
-	players     map[int]shared.PlayerState
-	nextID      int
-	lastSeqNums map[int]int
+	mu           mutexMedido          // Protege tudo abaixo; mede a espera para as métricas
+	salvando     sync.Mutex           // Um snapshot por vez, por causa da rotação do journal
+	rooms        map[string]*Room     // Salas pelo nome
+	playerRoom   map[int]*Room        // Sala de cada jogador
+	nextID       int                  // IDs são únicos entre todas as salas
+	lastSeen     map[int]time.Time    // Último sinal de vida de cada jogador
+	sessions     map[string]int       // Token de sessão -> ID do jogador
+	contas       *Contas              // Usuários cadastrados, com trava própria
+	contaDe      map[int]string       // Conta com que cada jogador entrou
+	mapaPadrao   string               // Mapa da sala padrão e de salas criadas sem mapa
+	journal      *Journal             // Log dos comandos aceitos, nil se desativado
+	journalIndex int64                // Índice da última entrada aplicada
+	ranking      *Ranking             // Recordes permanentes dos jogadores
+	maxPlayers   int                  // Limite de jogadores conectados, 0 sem limite
+	banidos      map[string]bool      // IPs que não podem entrar
+	enderecos    map[int]string       // IP de cada jogador conectado nesta execução
+	limite       limiteMovimentos     // Taxa máxima de UpdateState por jogador
+	buckets      map[int]*tokenBucket // Fichas de movimento de cada jogador
+	descartados  int64                // Movimentos descartados pelo limite desde que o servidor subiu
+	duplicados   int64                // Comandos ignorados por sequence number repetido
+	metricas     *Metricas            // Chamadas RPC e espera por mu
 }
 
-// GameService implementa os métodos RPC
+// novoServerState cria o estado vazio com a sala padrão. A espera por mu é
+// medida desde o início, antes de qualquer goroutine poder travá-lo.
+func novoServerState(cfg Config, principal *Room, contas *Contas) *ServerState {
+	metricas := novasMetricas()
+	return &ServerState{
+		mu:         mutexMedido{observar: metricas.observarEspera},
+		rooms:      map[string]*Room{salaPadrao: principal},
+		playerRoom: make(map[int]*Room),
+		nextID:     1,
+		lastSeen:   make(map[int]time.Time),
+		sessions:   make(map[string]int),
+		contas:     contas,
+		contaDe:    make(map[int]string),
+		mapaPadrao: cfg.Mapa,
+		maxPlayers: cfg.MaxPlayers,
+		banidos:    make(map[string]bool),
+		enderecos:  make(map[int]string),
+		limite:     limiteMovimentos{taxa: cfg.MoveRate, rajada: float64(cfg.MoveBurst)},
+		buckets:    make(map[int]*tokenBucket),
+		metricas:   metricas,
+		ranking:    &Ranking{entradas: make(map[string]*shared.LeaderboardEntry)},
+	}
+}
+
+// GameService implementa os métodos RPC. Cada conexão tem o seu,
+// para o Connect saber de onde o jogador veio.
 type GameService struct {
-	state *ServerState
+	state  *ServerState
+	remoto string // IP do cliente desta conexão
 }
 
-// Connect registra um novo jogador
+// Connect confere as credenciais e registra um novo jogador
 func (s *GameService) Connect(args *shared.ConnectArgs, reply *shared.ConnectReply) error {
+	if args.Protocol != shared.ProtocolVersion {
+		logInfo("[RPC] Connect de %s recusado: protocolo %d", s.remoto, args.Protocol)
+		return &shared.RejectError{
+			Reason: shared.RejectProtocolMismatch,
+			Detail: fmt.Sprintf("o servidor usa o protocolo %d e o cliente o %d", shared.ProtocolVersion, args.Protocol),
+		}
+	}
+	// O hash da senha é lento, então é conferido antes de travar o estado
+	if !s.state.contas.verificar(args.Username, args.Password) {
+		logInfo("[RPC] Connect de %s recusado: credenciais inválidas para %q", s.remoto, args.Username)
+		return &shared.RejectError{Reason: shared.RejectBadCredentials, Detail: "usuário ou senha inválidos"}
+	}
+
 	s.state.mu.Lock()
 	defer s.state.mu.Unlock()
 
+	if s.state.banidos[s.remoto] {
+		logInfo("[RPC] Connect de %s recusado: banido", s.remoto)
+		return &shared.RejectError{Reason: shared.RejectBanned, Detail: "este endereço foi banido do servidor"}
+	}
+	nomeSala := args.Room
+	if nomeSala == "" {
+		nomeSala = salaPadrao
+	}
+	room, ok := s.state.rooms[nomeSala]
+	if !ok {
+		return &shared.RejectError{Reason: shared.RejectRoomNotFound, Detail: fmt.Sprintf("a sala %q não existe", nomeSala)}
+	}
+
+	// Uma conta joga com um jogador só: quem entra de novo derruba a sessão
+	// anterior, então ela não conta para o limite de jogadores
+	var antigos []int
+	for _, id := range slices.Sorted(maps.Keys(s.state.contaDe)) {
+		if s.state.contaDe[id] == args.Username {
+			antigos = append(antigos, id)
+		}
+	}
+	if s.state.maxPlayers > 0 && len(s.state.playerRoom)-len(antigos) >= s.state.maxPlayers {
+		logInfo("[RPC] Connect de %s recusado: servidor cheio", s.remoto)
+		return &shared.RejectError{
+			Reason: shared.RejectServerFull,
+			Detail: fmt.Sprintf("o servidor já tem %d de %d jogadores", len(s.state.playerRoom), s.state.maxPlayers),
+		}
+	}
+
+	// Escolhe onde o jogador vai nascer
+	spawn, ok := room.escolherSpawn()
+	if !ok {
+		return errors.New(shared.ErrNoSpawn)
+	}
+
+	for _, antigo := range antigos {
+		if err := s.state.registrar(journalEntry{Op: opKick, PlayerID: antigo, Reason: "entrou de novo em outra conexão"}); err != nil {
+			return err
+		}
+	}
+
 	newID := s.state.nextID
-	s.state.nextID++ // Incrementa para o próximo jogador
 
-	// Posição inicial
-	newState := shared.PlayerState{PosX: 1, PosY: 1}
-	s.state.players[newID] = newState // Adiciona ao mapa
-	s.state.lastSeqNums[newID] = 0    // Inicializa o sequence number
+	// Adiciona ao mapa na posição inicial, incrementando nextID
+	token := novoToken()
+	err := s.state.registrar(journalEntry{
+		Op:       opConnect,
+		PlayerID: newID,
+		Room:     nomeSala,
+		X:        spawn.X,
+		Y:        spawn.Y,
+		Token:    token,
+		Account:  args.Username,
+	})
+	if err != nil {
+		return err
+	}
 
-	// Retorna o ID e uma cópia do mapa de jogadores
+	s.state.enderecos[newID] = s.remoto
+
+	// Retorna o ID, o mapa da sala e uma cópia dos jogadores dela
 	reply.PlayerID = newID
+	reply.SessionToken = token
+	reply.Room = nomeSala
+	reply.PosX, reply.PosY = spawn.X, spawn.Y
+	reply.MapLines = room.mapa.Linhas
+	reply.MapVersion = room.mapaVersao
 	reply.AllPlayers = make(map[int]shared.PlayerState)
-	maps.Copy(reply.AllPlayers, s.state.players) // retorna uma cópia dos players atuais
+	maps.Copy(reply.AllPlayers, room.players) // retorna uma cópia dos players atuais
 
-	log.Printf("[RPC] Connect -> ID: %d, Players: %v", newID, reply.AllPlayers)
+	logInfo("[RPC] Connect de %s -> ID: %d, Players: %v", args.Username, newID, reply.AllPlayers)
 	return nil
 }
 
 // função para atualizar o estado do jogador
 func (s *GameService) UpdateState(args *shared.UpdateStateArgs, reply *shared.UpdateStateReply) error {
+	s.state.mu.Lock()
+	id := s.state.jogadorDa(args.SessionToken)
+	s.state.mu.Unlock()
+
+	// Comandos rápidos demais esperam a vez ou são descartados
+	if !s.state.aguardarVez(id) {
+		s.state.mu.Lock()
+		defer s.state.mu.Unlock()
+		if room, ok := s.state.playerRoom[id]; ok {
+			atual := room.players[id]
+			reply.PosX, reply.PosY = atual.PosX, atual.PosY
+		}
+		reply.Reason = shared.MoveRateLimited
+		return nil
+	}
+
 	s.state.mu.Lock()
 	defer s.state.mu.Unlock()
 
 	// lógica "EXACLY-ONCE"
-	lastSeq, ok := s.state.lastSeqNums[args.PlayerID]
+	room, ok := s.state.playerRoom[id]
 	if !ok {
 		// Jogador não existe, ignora
+		reply.Reason = shared.MoveUnknown
 		return nil
 	}
+	s.state.touch(id)
+
+	lastSeq := room.lastSeqNums[id]
+	atual := room.players[id]
+	reply.PosX, reply.PosY = atual.PosX, atual.PosY
 
 	// Se o comando for antigo (menor) ou igual ao último processado, ignora
 	if args.SequenceNumber <= lastSeq {
-		log.Printf("[Seq] Comando %d ignorado (último foi %d)", args.SequenceNumber, lastSeq)
+		logDebug("[Seq] Comando %d ignorado (último foi %d)", args.SequenceNumber, lastSeq)
+		s.state.duplicados++
+		reply.Accepted = true
 		return nil // Sucesso, mas não faz nada
 	}
 
-	// Comando é novo, processa e atualiza
-	s.state.lastSeqNums[args.PlayerID] = args.SequenceNumber // Atualiza o último sequence number
-	// Atualiza a posição do jogador
-	s.state.players[args.PlayerID] = shared.PlayerState{
-		PosX: args.NewX,
-		PosY: args.NewY,
+	// Comando é novo, valida o movimento antes de aceitar a nova posição
+	motivo := room.validarMovimento(id, atual, args.NewX, args.NewY)
+	destX, destY := args.NewX, args.NewY
+
+	// Pisar no portal leva o jogador para uma célula aleatória
+	teleporte := motivo == shared.MoveOK && room.noPortal(args.NewX, args.NewY)
+	if teleporte {
+		if p, ok := room.celulaVaziaAleatoria(); ok {
+			destX, destY = p.X, p.Y
+		}
+	}
+	// Pegar a moeda abre o portal
+	pegouMoeda := motivo == shared.MoveOK && room.moeda.Active && room.moeda.X == destX && room.moeda.Y == destY
+
+	// Atualiza o último sequence number e, se válido, a posição do jogador
+	err := s.state.registrar(journalEntry{
+		Op:       opUpdate,
+		PlayerID: id,
+		Seq:      args.SequenceNumber,
+		X:        destX,
+		Y:        destY,
+		Accepted: motivo == shared.MoveOK,
+		Reason:   string(motivo),
+	})
+	if err != nil {
+		return err
+	}
+
+	if motivo != shared.MoveOK {
+		logDebug("[Mov] ID %d: movimento (%d, %d) -> (%d, %d) recusado: %s",
+			id, atual.PosX, atual.PosY, args.NewX, args.NewY, motivo)
+		reply.Reason = motivo
+
+		// Trombar em um inimigo machuca
+		if motivo == shared.MoveBlocked && room.noInimigo(args.NewX, args.NewY) && room.podeLevarDano(id, time.Now()) {
+			return s.state.causarDano(room, id, true)
+		}
+		return nil
+	}
+
+	if pegouMoeda {
+		s.state.ranking.moeda(s.state.contaDe[id])
 	}
+	if teleporte {
+		s.state.ranking.portal(s.state.contaDe[id])
+		logInfo("[Portal] ID %d teletransportado para (%d, %d) na sala %s", id, destX, destY, room.nome)
+		if err := s.state.registrar(journalEntry{Op: opPortalClose, Room: room.nome, PlayerID: id}); err != nil {
+			return err
+		}
+	}
+	if pegouMoeda && !room.portal.Active {
+		if p, ok := room.celulaVaziaAleatoria(); ok {
+			if err := s.state.registrar(journalEntry{Op: opPortalOpen, Room: room.nome, X: p.X, Y: p.Y}); err != nil {
+				return err
+			}
+		}
+	}
+
+	reply.Accepted = true
+	reply.PosX, reply.PosY = destX, destY
 	return nil
 }
 
+// validarMovimento verifica se o jogador pode ir de atual para (x, y).
+// Deve ser chamada com mu travado.
+func (room *Room) validarMovimento(id int, atual shared.PlayerState, x, y int) shared.MoveRejection {
+	if atual.Dead {
+		return shared.MoveDead
+	}
+	dx, dy := x-atual.PosX, y-atual.PosY
+	if dx*dx+dy*dy > 1 {
+		return shared.MoveTooFar
+	}
+	if !room.mapa.dentro(x, y) {
+		return shared.MoveOutOfBounds
+	}
+	if room.bloqueada(x, y) {
+		return shared.MoveBlocked
+	}
+	if !room.celulaLivre(x, y, id) {
+		return shared.MoveOccupied
+	}
+	return shared.MoveOK
+}
+
 // GetState envia a lista de Posições para o cliente
 func (s *GameService) GetState(args *shared.GetStateArgs, reply *shared.GetStateReply) error {
 	s.state.mu.Lock()
 	defer s.state.mu.Unlock()
 
+	_, room, err := s.state.salaDaSessao(args.SessionToken)
+	if err != nil {
+		return err
+	}
+
 	// Retorna uma cópia do mapa
+	reply.Version = room.version
 	reply.AllPlayers = make(map[int]shared.PlayerState)
-	for id, pos := range s.state.players {
+	for id, pos := range room.players {
 		reply.AllPlayers[id] = pos
 	}
 
-	log.Printf("[RPC] GetState -> Players: %v", reply.AllPlayers)
+	logDebug("[RPC] GetState -> Players: %v", reply.AllPlayers)
 	return nil
 }
 
@@ -92,44 +322,254 @@ func (s *GameService) Disconnect(args *shared.DisconnectArgs, reply *shared.Disc
 	s.state.mu.Lock()
 	defer s.state.mu.Unlock()
 
-	log.Printf("[RPC] Disconnect <- ID: %d", args.PlayerID)
+	id := s.state.jogadorDa(args.SessionToken)
+	logInfo("[RPC] Disconnect <- ID: %d", id)
 
-	lastSeq, ok := s.state.lastSeqNums[args.PlayerID]
+	room, ok := s.state.playerRoom[id]
 	if !ok {
 		return nil // Jogador já saiu
 	}
+	lastSeq := room.lastSeqNums[id]
 	if args.SequenceNumber <= lastSeq {
-		log.Printf("[Seq] Disconnect %d ignorado (último foi %d)", args.SequenceNumber, lastSeq)
+		logDebug("[Seq] Disconnect %d ignorado (último foi %d)", args.SequenceNumber, lastSeq)
+		s.state.duplicados++
 		return nil
 	}
 
-	s.state.lastSeqNums[args.PlayerID] = args.SequenceNumber
-	delete(s.state.players, args.PlayerID)     // Remove o jogador
-	delete(s.state.lastSeqNums, args.PlayerID) // Limpa
-	return nil
+	return s.state.registrar(journalEntry{
+		Op:       opDisconnect,
+		PlayerID: id,
+		Seq:      args.SequenceNumber,
+		Reason:   "desconectou",
+	})
 }
 
 func main() {
+	replay := flag.Bool("replay", false, "reconstrói o estado a partir do snapshot e do journal, imprime e sai")
+	replayUntil := flag.Int64("replay-until", 0, "com -replay, para na entrada com esse índice")
+	cfg, err := lerConfig()
+	if err != nil {
+		log.Fatal("Configuração inválida:\n", err)
+	}
+	nivelMinimo = niveisLog[cfg.LogLevel]
+
+	// Mostra com o que o servidor está rodando, já somando arquivo e flags
+	efetiva, _ := json.MarshalIndent(cfg, "", "  ")
+	log.Printf("[Config] Configuração efetiva:\n%s", efetiva)
+
+	// Cria a sala padrão, carregando o mapa para validar os movimentos
+	principal, err := novaSala(salaPadrao, cfg.Mapa)
+	if err != nil {
+		log.Fatal("Erro ao carregar mapa:", err)
+	}
+
+	contas, err := abrirContas(cfg.Accounts)
+	if err != nil {
+		log.Fatal("Erro ao carregar contas:", err)
+	}
+
 	// Inicializa o estado do servidor
-	serverState := &ServerState{
-		players:     make(map[int]shared.PlayerState),
-		nextID:      1,
-		lastSeqNums: make(map[int]int),
+	serverState := novoServerState(cfg, principal, contas)
+
+	// Modo de reprodução: reaplica o journal do zero e mostra o resultado
+	if *replay {
+		reproduzirJournal(serverState, cfg.State, cfg.Journal, *replayUntil)
+		return
+	}
+
+	// Restaura o estado da execução anterior e salva periodicamente
+	if cfg.State != "" {
+		if err := serverState.carregarEstado(cfg.State); err != nil {
+			log.Fatal("Erro ao restaurar estado:", err)
+		}
 	}
 
-	// Cria o serviço RPC
-	gameService := &GameService{state: serverState}
-	// Registra o serviço RPC
-	rpc.Register(gameService)
+	// Reaplica os comandos aceitos depois do último snapshot e continua o journal
+	if cfg.Journal != "" {
+		serverState.mu.Lock()
+		n, err := serverState.replayJournal(cfg.Journal, 0)
+		serverState.mu.Unlock()
+		if err != nil {
+			log.Fatal("Erro ao reaplicar journal:", err)
+		}
+		logInfo("[Journal] %d comandos reaplicados de %s", n, cfg.Journal)
+
+		serverState.journal, err = abrirJournal(cfg.Journal)
+		if err != nil {
+			log.Fatal("Erro ao abrir journal:", err)
+		}
+	}
+
+	if cfg.State != "" {
+		go serverState.snapshotManager(cfg.State, cfg.SnapshotInterval.Duration)
+	}
+
+	// Placar permanente, independente do estado das partidas
+	if cfg.Leaderboard != "" {
+		if err := serverState.ranking.carregar(cfg.Leaderboard); err != nil {
+			log.Fatal("Erro ao carregar placar:", err)
+		}
+		go serverState.rankingManager(cfg.Leaderboard, cfg.SnapshotInterval.Duration)
+	}
+
+	// Salva tudo uma última vez ao ser interrompido
+	go salvarAoEncerrar(func() error {
+		if cfg.State == "" {
+			return nil
+		}
+		logInfo("[Estado] Salvando em %s", cfg.State)
+		return serverState.salvarEstado(cfg.State)
+	}, func() error {
+		if cfg.Leaderboard == "" {
+			return nil
+		}
+		return serverState.salvarRanking(cfg.Leaderboard)
+	})
+
+	// Remove jogadores que sumiram sem chamar Disconnect
+	go serverState.reaper(cfg.IdleTimeout.Duration)
+	// Moedas são as mesmas para todos os jogadores de cada sala
+	go serverState.coinManager()
+	// O portal é o mesmo para todos e expira no servidor
+	go serverState.portalManager(cfg.tick())
+	// O pato é simulado uma vez só, no servidor
+	go serverState.patoManager()
+	// Inimigos patrulham e perseguem os jogadores
+	go serverState.inimigoManager(configInimigos{passosPorSegundo: cfg.EnemySpeed, alcance: cfg.EnemyAggro})
+	// Jogadores mortos renascem num ponto de nascimento
+	go serverState.respawnManager(cfg.tick())
+	// Console de administração na entrada padrão
+	if cfg.Admin {
+		go serverState.adminConsole(os.Stdin, os.Stdout)
+	}
+
+	// Métricas no formato do Prometheus
+	if cfg.MetricsAddr != "" {
+		go serverState.servirMetricas(cfg.MetricsAddr, serverState.metricas)
+	}
+
+	// Página para assistir às salas pelo navegador
+	if cfg.SpectatorAddr != "" {
+		go serverState.servirEspectador(cfg.SpectatorAddr)
+	}
+
+	for _, ip := range cfg.Banned {
+		serverState.banidos[ip] = true
+	}
 
 	// abre a porta do servidor
-	listener, err := net.Listen("tcp", ":12345")
+	listener, err := net.Listen("tcp", cfg.Listen)
 	if err != nil {
 		log.Fatal("Erro ao ouvir:", err)
 	}
 	defer listener.Close()
 
-	log.Println("Servidor RPC rodando na porta 12345")
+	// Com certificado, os listeners RPC e o endpoint HTTP só falam TLS
+	var tlsConfig *tls.Config
+	if cfg.TLSCert != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			log.Fatal("Erro ao carregar certificado:", err)
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		listener = tls.NewListener(listener, tlsConfig)
+		logInfo("[TLS] Usando o certificado %s", cfg.TLSCert)
+	}
+
+	// A mesma API em JSON, para clientes em outras linguagens
+	if cfg.JSONListen != "" {
+		jsonListener, err := net.Listen("tcp", cfg.JSONListen)
+		if err != nil {
+			log.Fatal("Erro ao ouvir JSON-RPC:", err)
+		}
+		if tlsConfig != nil {
+			jsonListener = tls.NewListener(jsonListener, tlsConfig)
+		}
+		logInfo("[JSON] JSON-RPC rodando em %s", jsonListener.Addr())
+		go aceitarConexoes(serverState, jsonListener, jsonrpc.NewServerCodec)
+	}
+	if cfg.HTTPRPCAddr != "" {
+		go serverState.servirRPCHTTP(cfg.HTTPRPCAddr, tlsConfig)
+	}
+
+	logInfo("Servidor RPC rodando em %s", listener.Addr())
 	// iniciar o loop de aceitação de conexões
-	rpc.Accept(listener)
+	aceitarConexoes(serverState, listener, novoCodecGob)
+}
+
+// aceitarConexoes atende cada conexão de listener com o codec dado
+func aceitarConexoes(st *ServerState, listener net.Listener, novoCodec func(io.ReadWriteCloser) rpc.ServerCodec) {
+	for {
+		conn, err := listener.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			log.Fatal("Erro ao aceitar conexão:", err)
+		}
+		go servirConexao(st, conn, novoCodec(conn))
+	}
+}
+
+// novoServidorRPC cria um servidor com um GameService próprio para o cliente em remoto
+func novoServidorRPC(st *ServerState, remoto string) *rpc.Server {
+	srv := rpc.NewServer()
+	srv.Register(&GameService{state: st, remoto: remoto})
+	return srv
+}
+
+// servirConexao atende os RPCs de uma conexão, medindo cada chamada
+func servirConexao(st *ServerState, conn net.Conn, codec rpc.ServerCodec) {
+	novoServidorRPC(st, hostDe(conn.RemoteAddr().String())).ServeCodec(medirCodec(codec, st.metricas))
+}
+
+// hostDe tira a porta de um endereço "host:porta"
+func hostDe(endereco string) string {
+	host, _, err := net.SplitHostPort(endereco)
+	if err != nil {
+		return endereco
+	}
+	return host
+}
+
+// salvarAoEncerrar executa os salvamentos uma última vez quando o servidor é interrompido
+func salvarAoEncerrar(salvamentos ...func() error) {
+	sinais := make(chan os.Signal, 1)
+	signal.Notify(sinais, os.Interrupt, syscall.SIGTERM)
+	sig := <-sinais
+
+	logInfo("[Estado] Recebido %v, encerrando", sig)
+	codigo := 0
+	for _, salvar := range salvamentos {
+		if err := salvar(); err != nil {
+			logErro("[Estado] Erro ao salvar: %v", err)
+			codigo = 1
+		}
+	}
+	os.Exit(codigo)
+}
+
+// reproduzirJournal reconstrói o estado a partir do snapshot em estado (se houver)
+// e do journal, e o imprime em JSON. O journal só guarda o que veio depois do
+// último snapshot, então sem ele não dá para reconstruir do zero.
+func reproduzirJournal(st *ServerState, estado, path string, ate int64) {
+	if estado != "" {
+		if err := st.carregarEstado(estado); err != nil {
+			log.Fatal("Erro ao restaurar estado:", err)
+		}
+	}
+	st.mu.Lock()
+	n, err := st.replayJournal(path, ate)
+	snap := st.snapshotLocked()
+	st.mu.Unlock()
+	if err != nil {
+		log.Fatal("Erro ao reaplicar journal:", err)
+	}
+	logInfo("[Journal] %d comandos reaplicados de %s", n, path)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(snap); err != nil {
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"jogo/shared"
+	"testing"
+)
+
+// jogadorTeste põe um jogador com a sessão token em (x, y) na sala padrão, como o Connect faz
+func jogadorTeste(t *testing.T, st *ServerState, token string, x, y int) int {
+	t.Helper()
+	id := st.nextID
+	if err := st.registrar(journalEntry{Op: opConnect, PlayerID: id, Room: salaPadrao, Token: token, X: x, Y: y}); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// Com o servidor cheio, quem entra de novo com a própria conta ainda entra:
+// a sessão antiga vai ser derrubada e não conta para o limite
+func TestConnectServidorCheio(t *testing.T) {
+	contas := contasTeste(t)
+	for i, usuario := range []string{"ana", "bia"} {
+		if err := contas.criar(fmt.Sprintf("10.0.0.%d", i), usuario, "segredo123"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := configPadrao()
+	cfg.MaxPlayers = 1
+	st := novoServerState(cfg, salaTeste(t, "▤▤▤▤▤", "▤☺  ▤", "▤▤▤▤▤"), contas)
+	servico := &GameService{state: st, remoto: "10.0.0.1"}
+	conectar := func(usuario string) error {
+		args := &shared.ConnectArgs{Username: usuario, Password: "segredo123", Protocol: shared.ProtocolVersion}
+		return servico.Connect(args, &shared.ConnectReply{})
+	}
+
+	if err := conectar("ana"); err != nil {
+		t.Fatal(err)
+	}
+	if err := conectar("ana"); err != nil {
+		t.Fatalf("reentrar com a mesma conta = %v, esperava entrar", err)
+	}
+	if len(st.playerRoom) != 1 {
+		t.Errorf("%d jogadores, esperava 1", len(st.playerRoom))
+	}
+
+	var re *shared.RejectError
+	if err := conectar("bia"); !errors.As(err, &re) || re.Reason != shared.RejectServerFull {
+		t.Errorf("outra conta com o servidor cheio = %v, esperava recusa por servidor cheio", err)
+	}
+}
+
+func TestUpdateStateValidacao(t *testing.T) {
+	// O jogador 1 está em (1, 1) e o 2 em (1, 2)
+	mapa := []string{
+		"▤▤▤▤▤▤",
+		"▤    ▤",
+		"▤ ▤  ▤",
+		"▤▤▤▤▤▤",
+	}
+	casos := []struct {
+		nome      string
+		x, y      int
+		seq       int
+		ultimoSeq int  // Último sequence number já processado
+		morto     bool // Jogador 1 morto antes do movimento
+		aceito    bool
+		motivo    shared.MoveRejection
+		fimX      int // Posição do jogador 1 depois
+		fimY      int
+	}{
+		{nome: "passo válido", x: 2, y: 1, seq: 1, aceito: true, fimX: 2, fimY: 1},
+		{nome: "diagonal", x: 2, y: 2, seq: 1, motivo: shared.MoveTooFar, fimX: 1, fimY: 1},
+		{nome: "pulo de duas células", x: 3, y: 1, seq: 1, motivo: shared.MoveTooFar, fimX: 1, fimY: 1},
+		{nome: "parede", x: 0, y: 1, seq: 1, motivo: shared.MoveBlocked, fimX: 1, fimY: 1},
+		{nome: "célula ocupada", x: 1, y: 2, seq: 1, motivo: shared.MoveOccupied, fimX: 1, fimY: 1},
+		{nome: "morto", x: 2, y: 1, seq: 1, morto: true, motivo: shared.MoveDead, fimX: 1, fimY: 1},
+		{nome: "sequência repetida", x: 2, y: 1, seq: 5, ultimoSeq: 5, aceito: true, fimX: 1, fimY: 1},
+		{nome: "sequência antiga", x: 2, y: 1, seq: 3, ultimoSeq: 5, aceito: true, fimX: 1, fimY: 1},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			st := novoServerState(configPadrao(), salaTeste(t, mapa...), nil)
+			id := jogadorTeste(t, st, "a", 1, 1)
+			jogadorTeste(t, st, "b", 1, 2)
+			room := st.rooms[salaPadrao]
+			room.lastSeqNums[id] = c.ultimoSeq
+			if c.morto {
+				p := room.players[id]
+				p.Dead = true
+				room.players[id] = p
+			}
+
+			reply := &shared.UpdateStateReply{}
+			args := &shared.UpdateStateArgs{SessionToken: "a", NewX: c.x, NewY: c.y, SequenceNumber: c.seq}
+			if err := (&GameService{state: st}).UpdateState(args, reply); err != nil {
+				t.Fatal(err)
+			}
+			if reply.Accepted != c.aceito || reply.Reason != c.motivo {
+				t.Errorf("resposta = aceito %v, motivo %q; esperava %v, %q", reply.Accepted, reply.Reason, c.aceito, c.motivo)
+			}
+			if reply.PosX != c.fimX || reply.PosY != c.fimY {
+				t.Errorf("resposta em (%d, %d), esperava (%d, %d)", reply.PosX, reply.PosY, c.fimX, c.fimY)
+			}
+			if p := room.players[id]; p.PosX != c.fimX || p.PosY != c.fimY {
+				t.Errorf("jogador em (%d, %d), esperava (%d, %d)", p.PosX, p.PosY, c.fimX, c.fimY)
+			}
+			// Recusado ou não, um comando novo consome o sequence number
+			if want := max(c.seq, c.ultimoSeq); room.lastSeqNums[id] != want {
+				t.Errorf("último sequence number = %d, esperava %d", room.lastSeqNums[id], want)
+			}
+		})
+	}
+}
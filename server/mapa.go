@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"jogo/shared"
+	"os"
+	"slices"
+)
+
+// Símbolos do mapa que o servidor precisa conhecer (os mesmos de client/types.go)
+const (
+	SimboloPersonagem = '☺'
+	SimboloInimigo    = '☠'
+	SimboloParede     = '▤'
+	SimboloVegetacao  = '♣'
+	SimboloVazio      = ' '
+	SimboloPato       = 'ࠎ'
+)
+
+// Mapa é a grade carregada do mesmo arquivo usado pelos clientes
+type Mapa struct {
+	Celulas  [][]rune
+	Linhas   []string  // Conteúdo original, enviado aos clientes
+	Spawns   []Posicao // Células marcadas com o personagem, onde jogadores nascem
+	Pato     *Posicao  // Posição inicial do pato, nil se o mapa não tem
+	Inimigos []Posicao // Posições iniciais dos inimigos
+}
+
+// Posicao é uma célula do mapa
+type Posicao struct {
+	X, Y int
+}
+
+// Lê o arquivo do mapa linha por linha
+func carregarMapa(nome string) (*Mapa, error) {
+	arq, err := os.Open(nome)
+	if err != nil {
+		return nil, err
+	}
+	defer arq.Close()
+
+	var linhas []string
+	scanner := bufio.NewScanner(arq)
+	for scanner.Scan() {
+		linhas = append(linhas, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return montarMapa(linhas)
+}
+
+// montarMapa monta a grade a partir das linhas do arquivo do mapa.
+// Recusa mapas sem nenhuma célula vazia, onde não há onde nascer nem pôr a moeda.
+func montarMapa(linhas []string) (*Mapa, error) {
+	mapa := &Mapa{}
+	for _, texto := range linhas {
+		mapa.Linhas = append(mapa.Linhas, texto)
+		linha := []rune(texto)
+		for x, ch := range linha {
+			// O personagem marca um ponto de nascimento; a célula fica vazia como no cliente
+			if ch == SimboloPersonagem {
+				mapa.Spawns = append(mapa.Spawns, Posicao{x, len(mapa.Celulas)})
+				linha[x] = SimboloVazio
+			}
+			// Inimigos e o pato andam, então são guardados fora da grade
+			if ch == SimboloInimigo {
+				mapa.Inimigos = append(mapa.Inimigos, Posicao{x, len(mapa.Celulas)})
+				linha[x] = SimboloVazio
+			}
+			if ch == SimboloPato && mapa.Pato == nil {
+				mapa.Pato = &Posicao{x, len(mapa.Celulas)}
+				linha[x] = SimboloVazio
+			}
+		}
+		mapa.Celulas = append(mapa.Celulas, linha)
+	}
+	for _, linha := range mapa.Celulas {
+		if slices.Contains(linha, SimboloVazio) {
+			return mapa, nil
+		}
+	}
+	return nil, errors.New(shared.ErrRoomMapInvalid)
+}
+
+// Verifica se (x, y) está dentro dos limites do mapa
+func (m *Mapa) dentro(x, y int) bool {
+	return y >= 0 && y < len(m.Celulas) && x >= 0 && x < len(m.Celulas[y])
+}
+
+// Verifica se a célula (x, y) bloqueia movimento (parede, inimigo, pato)
+func (m *Mapa) tangivel(x, y int) bool {
+	switch m.Celulas[y][x] {
+	case SimboloParede, SimboloInimigo, SimboloPato:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"jogo/shared"
+	"testing"
+)
+
+// Sem célula vazia não há onde nascer nem onde sortear a moeda
+func TestMontarMapaSemCelulaLivre(t *testing.T) {
+	casos := []struct {
+		nome   string
+		linhas []string
+		valido bool
+	}{
+		{"arquivo vazio", nil, false},
+		{"linhas vazias", []string{"", ""}, false},
+		{"só paredes", []string{"▤▤▤", "▤♣▤", "▤▤▤"}, false},
+		{"um vazio", []string{"▤▤▤", "▤ ▤", "▤▤▤"}, true},
+		{"só o ponto de nascimento", []string{"▤▤▤", "▤☺▤", "▤▤▤"}, true},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			mapa, err := montarMapa(c.linhas)
+			if c.valido {
+				if err != nil || mapa == nil {
+					t.Fatalf("montarMapa = %v, %v; esperava um mapa", mapa, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != shared.ErrRoomMapInvalid {
+				t.Fatalf("montarMapa = %v, esperava %q", err, shared.ErrRoomMapInvalid)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limites dos buckets, em segundos. O WaitForState segura até waitStateTimeout.
+var (
+	bucketsRPC   = []float64{0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10, 15}
+	bucketsTrava = []float64{0.000001, 0.00001, 0.0001, 0.001, 0.01, 0.1, 1}
+)
+
+// histograma no formato do Prometheus: contagens por bucket, soma e total
+type histograma struct {
+	limites   []float64
+	contagens []uint64 // contagens[i] conta as observações <= limites[i] e > limites[i-1]
+	soma      float64
+	total     uint64
+}
+
+func novoHistograma(limites []float64) *histograma {
+	return &histograma{limites: limites, contagens: make([]uint64, len(limites))}
+}
+
+func (h *histograma) observar(v float64) {
+	if i, _ := slices.BinarySearch(h.limites, v); i < len(h.limites) {
+		h.contagens[i]++
+	}
+	h.soma += v
+	h.total++
+}
+
+// escrever imprime as séries do histograma; rotulos vai antes de le, ex. `method="x",`
+func (h *histograma) escrever(w io.Writer, nome, rotulos string) {
+	var acumulado uint64
+	for i, lim := range h.limites {
+		acumulado += h.contagens[i]
+		fmt.Fprintf(w, "%s_bucket{%sle=%q} %d\n", nome, rotulos, strconv.FormatFloat(lim, 'g', -1, 64), acumulado)
+	}
+	fmt.Fprintf(w, "%s_bucket{%sle=\"+Inf\"} %d\n", nome, rotulos, h.total)
+	rotulos = trimVirgula(rotulos)
+	fmt.Fprintf(w, "%s_sum%s %g\n", nome, chaves(rotulos), h.soma)
+	fmt.Fprintf(w, "%s_count%s %d\n", nome, chaves(rotulos), h.total)
+}
+
+func trimVirgula(s string) string {
+	if len(s) > 0 && s[len(s)-1] == ',' {
+		return s[:len(s)-1]
+	}
+	return s
+}
+
+// rotulo escapa o valor de um rótulo como o formato texto do Prometheus pede:
+// só \, " e quebra de linha. O %q do Go escaparia outras coisas de outro jeito.
+var rotulo = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace
+
+// chaves envolve os rótulos em {}, ou nada se não houver rótulos
+func chaves(rotulos string) string {
+	if rotulos == "" {
+		return ""
+	}
+	return "{" + rotulos + "}"
+}
+
+// metricaMetodo acumula as chamadas de um método RPC
+type metricaMetodo struct {
+	ok, erros int64
+	latencia  *histograma
+}
+
+// Metricas guarda o que é exposto em /metrics. Tem sua própria trava
+// para não disputar (nem distorcer a medição de) ServerState.mu.
+type Metricas struct {
+	mu          sync.Mutex
+	metodos     map[string]*metricaMetodo
+	esperaTrava *histograma
+}
+
+func novasMetricas() *Metricas {
+	return &Metricas{
+		metodos:     make(map[string]*metricaMetodo),
+		esperaTrava: novoHistograma(bucketsTrava),
+	}
+}
+
+// observarChamada registra uma chamada RPC respondida
+func (m *Metricas) observarChamada(metodo string, d time.Duration, falhou bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mm, ok := m.metodos[metodo]
+	if !ok {
+		mm = &metricaMetodo{latencia: novoHistograma(bucketsRPC)}
+		m.metodos[metodo] = mm
+	}
+	if falhou {
+		mm.erros++
+	} else {
+		mm.ok++
+	}
+	mm.latencia.observar(d.Seconds())
+}
+
+// observarEspera registra quanto tempo alguém esperou por ServerState.mu
+func (m *Metricas) observarEspera(d time.Duration) {
+	m.mu.Lock()
+	m.esperaTrava.observar(d.Seconds())
+	m.mu.Unlock()
+}
+
+// mutexMedido é um sync.Mutex que informa quanto cada Lock esperou
+type mutexMedido struct {
+	sync.Mutex
+	observar func(time.Duration) // nil não mede nada
+}
+
+func (m *mutexMedido) Lock() {
+	if m.observar == nil {
+		m.Mutex.Lock()
+		return
+	}
+	inicio := time.Now()
+	m.Mutex.Lock()
+	m.observar(time.Since(inicio))
+}
+
+// escreverMetricas imprime todas as métricas no formato texto do Prometheus
+func (st *ServerState) escreverMetricas(w io.Writer, m *Metricas) {
+	// Primeiro o que vem do estado do jogo, para não segurar as duas travas juntas
+	st.mu.Lock()
+	jogadores := make(map[string]int)
+	for nome, room := range st.rooms {
+		jogadores[nome] = len(room.players)
+	}
+	duplicados, descartados := st.duplicados, st.descartados
+	st.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP jogo_players Jogadores conectados por sala.")
+	fmt.Fprintln(w, "# TYPE jogo_players gauge")
+	for _, nome := range slices.Sorted(maps.Keys(jogadores)) {
+		fmt.Fprintf(w, "jogo_players{room=\"%s\"} %d\n", rotulo(nome), jogadores[nome])
+	}
+
+	fmt.Fprintln(w, "# HELP jogo_duplicate_sequence_total Comandos ignorados por repetirem um SequenceNumber já processado.")
+	fmt.Fprintln(w, "# TYPE jogo_duplicate_sequence_total counter")
+	fmt.Fprintf(w, "jogo_duplicate_sequence_total %d\n", duplicados)
+
+	fmt.Fprintln(w, "# HELP jogo_rate_limited_moves_total Movimentos descartados pelo limite por jogador.")
+	fmt.Fprintln(w, "# TYPE jogo_rate_limited_moves_total counter")
+	fmt.Fprintf(w, "jogo_rate_limited_moves_total %d\n", descartados)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	metodos := slices.Sorted(maps.Keys(m.metodos))
+	fmt.Fprintln(w, "# HELP jogo_rpc_requests_total Chamadas RPC respondidas, por método e resultado.")
+	fmt.Fprintln(w, "# TYPE jogo_rpc_requests_total counter")
+	for _, metodo := range metodos {
+		mm := m.metodos[metodo]
+		fmt.Fprintf(w, "jogo_rpc_requests_total{method=\"%s\",result=\"ok\"} %d\n", rotulo(metodo), mm.ok)
+		fmt.Fprintf(w, "jogo_rpc_requests_total{method=\"%s\",result=\"error\"} %d\n", rotulo(metodo), mm.erros)
+	}
+
+	fmt.Fprintln(w, "# HELP jogo_rpc_duration_seconds Tempo entre receber e responder cada chamada RPC.")
+	fmt.Fprintln(w, "# TYPE jogo_rpc_duration_seconds histogram")
+	for _, metodo := range metodos {
+		m.metodos[metodo].latencia.escrever(w, "jogo_rpc_duration_seconds", fmt.Sprintf("method=\"%s\",", rotulo(metodo)))
+	}
+
+	fmt.Fprintln(w, "# HELP jogo_state_mutex_wait_seconds Espera para travar ServerState.mu.")
+	fmt.Fprintln(w, "# TYPE jogo_state_mutex_wait_seconds histogram")
+	m.esperaTrava.escrever(w, "jogo_state_mutex_wait_seconds", "")
+}
+
+// servirMetricas expõe /metrics em addr
+func (st *ServerState) servirMetricas(addr string, m *Metricas) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		st.escreverMetricas(w, m)
+	})
+	logInfo("[Métricas] Servindo em http://%s/metrics", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		logErro("[Métricas] Erro no servidor HTTP: %v", err)
+	}
+}
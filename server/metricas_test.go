@@ -0,0 +1,145 @@
+package main
+
+import (
+	"net/rpc"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Saída esperada de TestEscreverMetricas, linha a linha
+const metricasEsperadas = `# HELP jogo_players Jogadores conectados por sala.
+# TYPE jogo_players gauge
+jogo_players{room="principal"} 1
+jogo_players{room="sala \"a\"\\b\nc"} 0
+# HELP jogo_duplicate_sequence_total Comandos ignorados por repetirem um SequenceNumber já processado.
+# TYPE jogo_duplicate_sequence_total counter
+jogo_duplicate_sequence_total 2
+# HELP jogo_rate_limited_moves_total Movimentos descartados pelo limite por jogador.
+# TYPE jogo_rate_limited_moves_total counter
+jogo_rate_limited_moves_total 1
+# HELP jogo_rpc_requests_total Chamadas RPC respondidas, por método e resultado.
+# TYPE jogo_rpc_requests_total counter
+jogo_rpc_requests_total{method="GameService.Connect",result="ok"} 1
+jogo_rpc_requests_total{method="GameService.Connect",result="error"} 1
+# HELP jogo_rpc_duration_seconds Tempo entre receber e responder cada chamada RPC.
+# TYPE jogo_rpc_duration_seconds histogram
+jogo_rpc_duration_seconds_bucket{method="GameService.Connect",le="0.0005"} 0
+jogo_rpc_duration_seconds_bucket{method="GameService.Connect",le="0.001"} 0
+jogo_rpc_duration_seconds_bucket{method="GameService.Connect",le="0.005"} 1
+jogo_rpc_duration_seconds_bucket{method="GameService.Connect",le="0.01"} 1
+jogo_rpc_duration_seconds_bucket{method="GameService.Connect",le="0.05"} 2
+jogo_rpc_duration_seconds_bucket{method="GameService.Connect",le="0.1"} 2
+jogo_rpc_duration_seconds_bucket{method="GameService.Connect",le="0.5"} 2
+jogo_rpc_duration_seconds_bucket{method="GameService.Connect",le="1"} 2
+jogo_rpc_duration_seconds_bucket{method="GameService.Connect",le="5"} 2
+jogo_rpc_duration_seconds_bucket{method="GameService.Connect",le="10"} 2
+jogo_rpc_duration_seconds_bucket{method="GameService.Connect",le="15"} 2
+jogo_rpc_duration_seconds_bucket{method="GameService.Connect",le="+Inf"} 2
+jogo_rpc_duration_seconds_sum{method="GameService.Connect"} 0.022
+jogo_rpc_duration_seconds_count{method="GameService.Connect"} 2
+# HELP jogo_state_mutex_wait_seconds Espera para travar ServerState.mu.
+# TYPE jogo_state_mutex_wait_seconds histogram
+jogo_state_mutex_wait_seconds_bucket{le="1e-06"} 0
+jogo_state_mutex_wait_seconds_bucket{le="1e-05"} 0
+jogo_state_mutex_wait_seconds_bucket{le="0.0001"} 0
+jogo_state_mutex_wait_seconds_bucket{le="0.001"} 1
+jogo_state_mutex_wait_seconds_bucket{le="0.01"} 1
+jogo_state_mutex_wait_seconds_bucket{le="0.1"} 1
+jogo_state_mutex_wait_seconds_bucket{le="1"} 1
+jogo_state_mutex_wait_seconds_bucket{le="+Inf"} 1
+jogo_state_mutex_wait_seconds_sum 0.0005
+jogo_state_mutex_wait_seconds_count 1
+`
+
+func TestEscreverMetricas(t *testing.T) {
+	st := novoServerState(configPadrao(), salaTeste(t, "▤▤▤▤▤", "▤   ▤", "▤▤▤▤▤"), nil)
+	jogadorTeste(t, st, "a", 1, 1)
+	// Aspas, barra e quebra de linha no nome precisam ser escapadas no rótulo
+	st.rooms["sala \"a\"\\b\nc"] = salaTeste(t, "▤▤▤", "▤ ▤", "▤▤▤")
+	st.duplicados, st.descartados = 2, 1
+
+	// Uma métrica própria, sem as esperas da trava vindas do próprio teste
+	m := novasMetricas()
+	m.observarChamada("GameService.Connect", 2*time.Millisecond, false)
+	m.observarChamada("GameService.Connect", 20*time.Millisecond, true)
+	m.observarEspera(500 * time.Microsecond)
+
+	var b strings.Builder
+	st.escreverMetricas(&b, m)
+	if b.String() != metricasEsperadas {
+		t.Errorf("saída diferente do esperado:\n%s", diferencaLinhas(b.String(), metricasEsperadas))
+	}
+}
+
+// diferencaLinhas mostra as linhas que mudaram entre obtido e esperado
+func diferencaLinhas(obtido, esperado string) string {
+	a, e := strings.Split(obtido, "\n"), strings.Split(esperado, "\n")
+	var b strings.Builder
+	for i := range max(len(a), len(e)) {
+		var la, le string
+		if i < len(a) {
+			la = a[i]
+		}
+		if i < len(e) {
+			le = e[i]
+		}
+		if la != le {
+			b.WriteString("linha " + strconv.Itoa(i+1) + ":\n  obtido:   " + la + "\n  esperado: " + le + "\n")
+		}
+	}
+	return b.String()
+}
+
+// Só \, " e quebra de linha são escapados; o resto vai como está, em UTF-8
+func TestRotulo(t *testing.T) {
+	casos := map[string]string{
+		"principal":    "principal",
+		`sala "a"`:     `sala \"a\"`,
+		`c:\mapas`:     `c:\\mapas`,
+		"duas\nlinhas": `duas\nlinhas`,
+		"com\ttab":     "com\ttab",
+		"sala ☺":       "sala ☺",
+	}
+	for valor, want := range casos {
+		if got := rotulo(valor); got != want {
+			t.Errorf("rotulo(%q) = %q, esperava %q", valor, got, want)
+		}
+	}
+}
+
+// codecFalso devolve uma requisição fixa e descarta as respostas
+type codecFalso struct{ metodo string }
+
+func (c *codecFalso) ReadRequestHeader(r *rpc.Request) error {
+	r.ServiceMethod, r.Seq = c.metodo, 1
+	return nil
+}
+func (c *codecFalso) ReadRequestBody(any) error              { return nil }
+func (c *codecFalso) WriteResponse(*rpc.Response, any) error { return nil }
+func (c *codecFalso) Close() error                           { return nil }
+
+// Um método inexistente não cria uma série com o nome que o cliente inventou
+func TestMetodoDesconhecido(t *testing.T) {
+	m := novasMetricas()
+	codec := medirCodec(&codecFalso{metodo: "GameService.Inventado"}, m)
+	var req rpc.Request
+	if err := codec.ReadRequestHeader(&req); err != nil {
+		t.Fatal(err)
+	}
+	resp := &rpc.Response{ServiceMethod: req.ServiceMethod, Seq: req.Seq, Error: "rpc: can't find method GameService.Inventado"}
+	if err := codec.WriteResponse(resp, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	novoServerState(configPadrao(), salaTeste(t, "▤▤▤", "▤ ▤", "▤▤▤"), nil).escreverMetricas(&b, m)
+	saida := b.String()
+	if !strings.Contains(saida, `jogo_rpc_requests_total{method="desconhecido",result="error"} 1`) {
+		t.Errorf("chamada sem método não contada como desconhecido:\n%s", saida)
+	}
+	if strings.Contains(saida, "Inventado") {
+		t.Errorf("nome inventado pelo cliente virou rótulo:\n%s", saida)
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Intervalo entre reposicionamentos da moeda em cada sala
+const coinInterval = 5 * time.Second
+
+// celulaVaziaAleatoria sorteia uma célula vazia e sem jogadores.
+// Desiste depois de algumas tentativas. Deve ser chamada com mu travado.
+func (room *Room) celulaVaziaAleatoria() (Posicao, bool) {
+	maxY := len(room.mapa.Celulas)
+	for range 100 {
+		y := rand.Intn(maxY)
+		if len(room.mapa.Celulas[y]) == 0 {
+			continue
+		}
+		x := rand.Intn(len(room.mapa.Celulas[y]))
+		if room.mapa.Celulas[y][x] == SimboloVazio && room.celulaLivre(x, y, 0) {
+			return Posicao{x, y}, true
+		}
+	}
+	return Posicao{}, false
+}
+
+// coinManager reposiciona a moeda de cada sala periodicamente
+func (st *ServerState) coinManager() {
+	ticker := time.NewTicker(coinInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		st.mu.Lock()
+		for _, room := range st.rooms {
+			st.passoMoeda(room)
+		}
+		st.mu.Unlock()
+	}
+}
+
+// passoMoeda reposiciona a moeda da sala. Deve ser chamada com mu travado.
+func (st *ServerState) passoMoeda(room *Room) {
+	// Enquanto o portal está aberto não há moeda: a que estava some,
+	// como a cada tick a moeda antiga sai antes de a nova aparecer
+	if room.portal.Active {
+		if room.moeda.Active {
+			st.registrarSimulacao(journalEntry{Op: opCoinClear, Room: room.nome})
+		}
+		return
+	}
+	if p, ok := room.celulaVaziaAleatoria(); ok {
+		st.registrarSimulacao(journalEntry{Op: opCoin, Room: room.nome, X: p.X, Y: p.Y})
+	}
+}
+
+// colocarMoeda põe a moeda da sala em (x, y). Deve ser chamada com mu travado.
+func (room *Room) colocarMoeda(x, y int) {
+	room.moeda.Active = true
+	room.moeda.X, room.moeda.Y = x, y
+	room.recordChange(0, changeWorld)
+}
+
+// tirarMoeda remove a moeda da sala sem dar a ninguém. Deve ser chamada com mu travado.
+func (room *Room) tirarMoeda() {
+	room.moeda.Active = false
+	room.recordChange(0, changeWorld)
+}
+
+// coletarMoeda dá a moeda ao jogador se ele acabou de pisar nela.
+// Como tudo acontece com mu travado, o primeiro movimento aplicado vence.
+func (room *Room) coletarMoeda(id int) {
+	p := room.players[id]
+	if !room.moeda.Active || room.moeda.X != p.PosX || room.moeda.Y != p.PosY {
+		return
+	}
+	p.Score++
+	room.players[id] = p
+	room.moeda.Active = false
+	room.moeda.LastTakenBy = id
+	room.recordChange(0, changeWorld)
+	logInfo("[Moeda] ID %d pegou a moeda na sala %s (total %d)", id, room.nome, p.Score)
+}
@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestPassoMoeda(t *testing.T) {
+	room := salaTeste(t, "▤▤▤▤▤", "▤   ▤", "▤▤▤▤▤")
+	st := &ServerState{rooms: map[string]*Room{room.nome: room}}
+
+	st.passoMoeda(room)
+	if !room.moeda.Active {
+		t.Fatal("nenhuma moeda apareceu com o portal fechado")
+	}
+	if room.mapa.Celulas[room.moeda.Y][room.moeda.X] != SimboloVazio {
+		t.Errorf("moeda em (%d, %d), fora de uma célula vazia", room.moeda.X, room.moeda.Y)
+	}
+
+	// Com o portal aberto a moeda que estava some e nenhuma aparece
+	room.abrirPortal(1, 1)
+	st.passoMoeda(room)
+	if room.moeda.Active {
+		t.Error("a moeda continua com o portal aberto")
+	}
+	st.passoMoeda(room)
+	if room.moeda.Active {
+		t.Error("apareceu uma moeda com o portal aberto")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"jogo/shared"
+	"time"
+)
+
+// Intervalo entre os passos do pato
+const duckInterval = time.Second
+
+// patoManager anda com o pato de cada sala enquanto o portal está aberto
+func (st *ServerState) patoManager() {
+	ticker := time.NewTicker(duckInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		st.mu.Lock()
+		for nome, room := range st.rooms {
+			if p, ok := room.proximoPassoPato(); ok {
+				st.registrarSimulacao(journalEntry{Op: opDuckMove, Room: nome, X: p.X, Y: p.Y})
+			}
+		}
+		st.mu.Unlock()
+	}
+}
+
+// proximoPassoPato decide para onde o pato vai: sempre para cima, se puder.
+// Deve ser chamada com mu travado.
+func (room *Room) proximoPassoPato() (Posicao, bool) {
+	pato := room.pato
+	if !pato.Present || pato.Stopped || !room.portal.Active {
+		return Posicao{}, false
+	}
+	novo := Posicao{pato.X, pato.Y - 1}
+	if !room.celulaLivre(novo.X, novo.Y, 0) {
+		return Posicao{}, false
+	}
+	return novo, true
+}
+
+// noPato verifica se o pato está em (x, y). Deve ser chamada com mu travado.
+func (room *Room) noPato(x, y int) bool {
+	return room.pato.Present && room.pato.X == x && room.pato.Y == y
+}
+
+// moverPato coloca o pato em (x, y). Deve ser chamada com mu travado.
+func (room *Room) moverPato(x, y int) {
+	room.pato.X, room.pato.Y = x, y
+	room.recordChange(0, changeWorld)
+}
+
+// pararPato para o pato; porID é o jogador que fez carinho, 0 se foi o portal.
+// Deve ser chamada com mu travado.
+func (room *Room) pararPato(porID int) {
+	room.pato.Stopped = true
+	room.pato.PettedBy = porID
+	room.recordChange(0, changeWorld)
+}
+
+// Interact faz carinho no pato se o jogador estiver ao lado dele, parando-o para todos
+func (s *GameService) Interact(args *shared.InteractArgs, reply *shared.InteractReply) error {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	id, room, err := s.state.salaDaSessao(args.SessionToken)
+	if err != nil {
+		return err
+	}
+	if !room.pato.Present {
+		return nil
+	}
+
+	p := room.players[id]
+	dx, dy := p.PosX-room.pato.X, p.PosY-room.pato.Y
+	if dx*dx+dy*dy > 1 {
+		return nil
+	}
+
+	if err := s.state.registrar(journalEntry{Op: opDuckPet, Room: room.nome, PlayerID: id}); err != nil {
+		return err
+	}
+	reply.Petted = true
+	logInfo("[Pato] ID %d fez carinho no pato da sala %s", id, room.nome)
+	return nil
+}
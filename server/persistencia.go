@@ -0,0 +1,197 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"jogo/shared"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// snapshot é o formato gravado em disco do ServerState
+type snapshot struct {
+	Rooms        map[string]roomSnapshot `json:"rooms"`
+	NextID       int                     `json:"next_id"`
+	JournalIndex int64                   `json:"journal_index"` // Última entrada do journal incluída
+	Sessions     map[string]int          `json:"sessions"`
+	Accounts     map[int]string          `json:"accounts"` // Conta de cada jogador conectado
+}
+
+// roomSnapshot é o estado salvo de uma sala
+type roomSnapshot struct {
+	Map         string                     `json:"map"`
+	MapLines    []string                   `json:"map_lines,omitempty"` // Conteúdo em uso, que pode vir de um reload-map
+	Players     map[int]shared.PlayerState `json:"players"`
+	LastSeqNums map[int]int                `json:"last_seq_nums"`
+	Version     int64                      `json:"version"`
+	Coin        shared.CoinState           `json:"coin"`
+	Portal      shared.PortalState         `json:"portal"`
+	Duck        shared.DuckState           `json:"duck"`
+	Enemies     []shared.EnemyState        `json:"enemies"`
+}
+
+// snapshotLocked copia o estado atual. Deve ser chamada com mu travado.
+func (st *ServerState) snapshotLocked() snapshot {
+	snap := snapshot{
+		Rooms:        make(map[string]roomSnapshot, len(st.rooms)),
+		NextID:       st.nextID,
+		JournalIndex: st.journalIndex,
+		Sessions:     make(map[string]int, len(st.sessions)),
+		Accounts:     maps.Clone(st.contaDe),
+	}
+	for nome, room := range st.rooms {
+		snap.Rooms[nome] = roomSnapshot{
+			Map:         room.mapaArquivo,
+			MapLines:    room.mapa.Linhas,
+			Players:     maps.Clone(room.players),
+			LastSeqNums: maps.Clone(room.lastSeqNums),
+			Version:     room.version,
+			Coin:        room.moeda,
+			Portal:      room.portal,
+			Duck:        room.pato,
+			Enemies:     room.inimigos,
+		}
+	}
+	maps.Copy(snap.Sessions, st.sessions)
+	return snap
+}
+
+// salvarEstado grava o estado em path de forma atômica e descarta
+// as entradas do journal que o snapshot já contém
+func (st *ServerState) salvarEstado(path string) error {
+	st.salvando.Lock()
+	defer st.salvando.Unlock()
+
+	st.mu.Lock()
+	snap := st.snapshotLocked()
+	// As entradas até snap.JournalIndex saem do journal atual junto com o snapshot
+	if st.journal != nil {
+		if err := st.journal.rotacionar(); err != nil {
+			st.mu.Unlock()
+			return err
+		}
+	}
+	st.mu.Unlock()
+
+	dados, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := escreverAtomico(path, dados); err != nil {
+		return err
+	}
+	if st.journal != nil {
+		return st.journal.descartarAnterior()
+	}
+	return nil
+}
+
+// escreverAtomico escreve num arquivo temporário na mesma pasta e renomeia
+// por cima de path, para que uma queda no meio nunca deixe o arquivo corrompido
+func escreverAtomico(path string, dados []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // Não faz nada se o rename deu certo
+
+	if _, err := tmp.Write(dados); err != nil {
+		tmp.Close()
+		return err
+	}
+	// Garante que os dados estão no disco antes do rename
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// carregarEstado restaura o estado salvo em path, se existir.
+// Deve ser chamada antes de o servidor aceitar conexões.
+func (st *ServerState) carregarEstado(path string) error {
+	dados, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil // Primeira execução, nada para restaurar
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap snapshot
+	if err := json.Unmarshal(dados, &snap); err != nil {
+		return err
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	agora := time.Now()
+	jogadores := 0
+	for nome, rs := range snap.Rooms {
+		room, ok := st.rooms[nome]
+		if !ok {
+			room, err = novaSala(nome, rs.Map)
+			if err != nil {
+				return err
+			}
+			st.rooms[nome] = room
+		}
+		// O arquivo pode ter mudado desde o snapshot; vale o mapa que estava em uso
+		if rs.MapLines != nil && !slices.Equal(rs.MapLines, room.mapa.Linhas) {
+			if err := room.recarregarMapa(rs.MapLines); err != nil {
+				return err
+			}
+		}
+		for id, p := range rs.Players {
+			room.players[id] = p
+			if p.Dead {
+				room.mortos[id] = agora
+			} else {
+				room.vivoDesde[id] = agora
+			}
+			st.playerRoom[id] = room
+			// Os jogadores restaurados têm o timeout normal para voltar
+			st.lastSeen[id] = agora
+			jogadores++
+		}
+		maps.Copy(room.lastSeqNums, rs.LastSeqNums)
+		room.version = rs.Version
+		room.logDesde = rs.Version // As mudanças de antes do snapshot não estão no log
+		room.moeda = rs.Coin
+		room.portal = rs.Portal
+		room.pato = rs.Duck
+		if rs.Enemies != nil {
+			room.inimigos = rs.Enemies
+		}
+		// O portal restaurado ganha um tempo inteiro para ser usado
+		room.portalExpira = agora.Add(portalDuration)
+	}
+	maps.Copy(st.sessions, snap.Sessions)
+	maps.Copy(st.contaDe, snap.Accounts)
+	if snap.NextID > st.nextID {
+		st.nextID = snap.NextID
+	}
+	st.journalIndex = snap.JournalIndex
+	logInfo("[Estado] Restaurado de %s: %d salas, %d jogadores, próximo ID %d", path, len(snap.Rooms), jogadores, st.nextID)
+	return nil
+}
+
+// snapshotManager salva o estado periodicamente
+func (st *ServerState) snapshotManager(path string, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := st.salvarEstado(path); err != nil {
+			logErro("[Estado] Erro ao salvar %s: %v", path, err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"time"
+)
+
+// Quanto tempo o portal fica aberto se ninguém usar
+const portalDuration = 15 * time.Second
+
+// portalManager fecha os portais que expiraram, conferindo a cada tick
+func (st *ServerState) portalManager(tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		st.mu.Lock()
+		for nome, room := range st.rooms {
+			if room.portal.Active && now.After(room.portalExpira) {
+				st.registrarSimulacao(journalEntry{Op: opPortalClose, Room: nome})
+			}
+		}
+		st.mu.Unlock()
+	}
+}
+
+// abrirPortal ativa o portal da sala em (x, y). Deve ser chamada com mu travado.
+func (room *Room) abrirPortal(x, y int) {
+	room.portal.Active = true
+	room.portal.X, room.portal.Y = x, y
+	room.portalExpira = time.Now().Add(portalDuration)
+	// Com o portal aberto o pato volta a andar
+	room.pato.Stopped = false
+	room.recordChange(0, changeWorld)
+	logInfo("[Portal] Aberto em (%d, %d) na sala %s", x, y, room.nome)
+}
+
+// fecharPortal desativa o portal; usadoPor é 0 quando ele expirou.
+// Deve ser chamada com mu travado.
+func (room *Room) fecharPortal(usadoPor int) {
+	if !room.portal.Active {
+		return
+	}
+	room.portal.Active = false
+	room.portal.LastUsedBy = usadoPor
+	room.pararPato(0)
+}
+
+// noPortal verifica se (x, y) é o portal ativo da sala. Deve ser chamada com mu travado.
+func (room *Room) noPortal(x, y int) bool {
+	return room.portal.Active && room.portal.X == x && room.portal.Y == y
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"jogo/shared"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Tamanho padrão do placar devolvido por GetLeaderboard
+const leaderboardSize = 10
+
+// Ranking guarda os recordes de cada conta entre reinícios do servidor.
+// Os IDs de jogador mudam a cada Connect, então a chave é o nome de usuário.
+// É protegido por ServerState.mu.
+type Ranking struct {
+	entradas map[string]*shared.LeaderboardEntry
+	sujo     bool // Mudou desde o último salvamento
+}
+
+// entrada retorna (criando se preciso) os recordes da conta.
+// Jogadores sem conta (de um snapshot antigo) não entram no placar.
+func (r *Ranking) entrada(usuario string) *shared.LeaderboardEntry {
+	if usuario == "" {
+		return &shared.LeaderboardEntry{}
+	}
+	e, ok := r.entradas[usuario]
+	if !ok {
+		e = &shared.LeaderboardEntry{Username: usuario}
+		r.entradas[usuario] = e
+	}
+	r.sujo = true
+	return e
+}
+
+// moeda conta uma moeda coletada
+func (r *Ranking) moeda(usuario string) {
+	r.entrada(usuario).Coins++
+}
+
+// portal conta um portal atravessado
+func (r *Ranking) portal(usuario string) {
+	r.entrada(usuario).Portals++
+}
+
+// sobreviveu registra uma vida que durou d, se for o recorde da conta
+func (r *Ranking) sobreviveu(usuario string, d time.Duration) {
+	e := r.entrada(usuario)
+	if segundos := int(d.Seconds()); segundos > e.BestSurvivalSecs {
+		e.BestSurvivalSecs = segundos
+	}
+}
+
+// carregar lê o placar salvo em path, se existir
+func (r *Ranking) carregar(path string) error {
+	dados, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var lista []shared.LeaderboardEntry
+	if err := json.Unmarshal(dados, &lista); err != nil {
+		return err
+	}
+	for _, e := range lista {
+		// Placares antigos eram por ID de jogador, que não identifica ninguém
+		if e.Username == "" {
+			continue
+		}
+		r.entradas[e.Username] = &e
+	}
+	logInfo("[Placar] %d contas carregadas de %s", len(r.entradas), path)
+	return nil
+}
+
+// salvarRanking grava o placar em path se ele mudou
+func (st *ServerState) salvarRanking(path string) error {
+	st.mu.Lock()
+	if !st.ranking.sujo {
+		st.mu.Unlock()
+		return nil
+	}
+	lista := st.ranking.lista()
+	st.ranking.sujo = false
+	st.mu.Unlock()
+
+	dados, err := json.MarshalIndent(lista, "", "  ")
+	if err != nil {
+		return err
+	}
+	return escreverAtomico(path, dados)
+}
+
+// rankingManager salva o placar periodicamente
+func (st *ServerState) rankingManager(path string, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := st.salvarRanking(path); err != nil {
+			logErro("[Placar] Erro ao salvar %s: %v", path, err)
+		}
+	}
+}
+
+// lista copia todas as entradas, ordenadas por nome
+func (r *Ranking) lista() []shared.LeaderboardEntry {
+	lista := make([]shared.LeaderboardEntry, 0, len(r.entradas))
+	for _, e := range r.entradas {
+		lista = append(lista, *e)
+	}
+	slices.SortFunc(lista, func(a, b shared.LeaderboardEntry) int {
+		return strings.Compare(a.Username, b.Username)
+	})
+	return lista
+}
+
+// GetLeaderboard devolve os melhores jogadores pelo critério pedido
+func (s *GameService) GetLeaderboard(args *shared.GetLeaderboardArgs, reply *shared.GetLeaderboardReply) error {
+	s.state.mu.Lock()
+	if _, _, err := s.state.salaDaSessao(args.SessionToken); err != nil {
+		s.state.mu.Unlock()
+		return err
+	}
+	lista := s.state.ranking.lista()
+
+	// Vidas em andamento também contam para o tempo de sobrevivência
+	agora := time.Now()
+	vidaAtual := make(map[string]int, len(s.state.contaDe)) // Conta -> segundos da vida em andamento
+	for id, usuario := range s.state.contaDe {
+		room, ok := s.state.playerRoom[id]
+		if !ok {
+			continue
+		}
+		if desde, vivo := room.vivoDesde[id]; vivo {
+			vidaAtual[usuario] = int(agora.Sub(desde).Seconds())
+		}
+	}
+	for i := range lista {
+		lista[i].BestSurvivalSecs = max(lista[i].BestSurvivalSecs, vidaAtual[lista[i].Username])
+	}
+	s.state.mu.Unlock()
+
+	criterio := func(e shared.LeaderboardEntry) int { return e.Coins }
+	switch args.OrderBy {
+	case shared.LeaderboardBySurvival:
+		criterio = func(e shared.LeaderboardEntry) int { return e.BestSurvivalSecs }
+	case shared.LeaderboardByPortals:
+		criterio = func(e shared.LeaderboardEntry) int { return e.Portals }
+	}
+	slices.SortStableFunc(lista, func(a, b shared.LeaderboardEntry) int {
+		return cmp.Compare(criterio(b), criterio(a)) // Decrescente
+	})
+
+	limite := args.Limit
+	if limite <= 0 {
+		limite = leaderboardSize
+	}
+	reply.Entries = lista[:min(limite, len(lista))]
+	return nil
+}
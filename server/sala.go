@@ -0,0 +1,152 @@
+package main
+
+import (
+	"errors"
+	"jogo/shared"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Nome da sala criada na inicialização e usada quando o cliente não escolhe
+const salaPadrao = "principal"
+
+// Room é uma partida independente: jogadores, sequence numbers e mapa próprios
+type Room struct {
+	nome         string
+	mapaArquivo  string // Arquivo do mapa, para salvar e recriar a sala
+	mapa         *Mapa  // Usado para validar movimentos
+	mapaVersao   int    // Incrementa a cada reload-map
+	players      map[int]shared.PlayerState
+	lastSeqNums  map[int]int
+	version      int64         // Incrementa a cada mudança no conjunto de jogadores
+	changed      chan struct{} // Fechado (e trocado) a cada mudança, acorda WaitForState
+	changeLog    []stateChange // Últimas mudanças de jogadores e do mapa, para GetStateDelta
+	logDesde     int64         // O changeLog tem todas as mudanças com versão maior que esta
+	moeda        shared.CoinState
+	portal       shared.PortalState
+	portalExpira time.Time
+	pato         shared.DuckState
+	inimigos     []shared.EnemyState
+	ultimoDano   map[int]time.Time            // Quando cada jogador levou dano pela última vez
+	mortos       map[int]time.Time            // Jogadores mortos e quando morreram
+	vivoDesde    map[int]time.Time            // Início da vida atual de cada jogador vivo
+	chat         [chatSize]shared.ChatMessage // Buffer circular, a mensagem n fica em n % chatSize
+	chatSeq      int64                        // Número da última mensagem
+}
+
+// novaSala cria uma sala vazia carregando o mapa de arquivo
+func novaSala(nome, arquivo string) (*Room, error) {
+	mapa, err := carregarMapa(arquivo)
+	if err != nil {
+		return nil, err
+	}
+	room := &Room{
+		nome:        nome,
+		mapaArquivo: arquivo,
+		mapa:        mapa,
+		players:     make(map[int]shared.PlayerState),
+		lastSeqNums: make(map[int]int),
+		changed:     make(chan struct{}),
+		ultimoDano:  make(map[int]time.Time),
+		mortos:      make(map[int]time.Time),
+		vivoDesde:   make(map[int]time.Time),
+	}
+	for _, p := range mapa.Inimigos {
+		room.inimigos = append(room.inimigos, shared.EnemyState{X: p.X, Y: p.Y})
+	}
+	if mapa.Pato != nil {
+		room.pato = shared.DuckState{Present: true, X: mapa.Pato.X, Y: mapa.Pato.Y}
+	}
+	return room, nil
+}
+
+// salaDaSessao retorna o jogador do token e a sala em que ele está.
+// Token desconhecido ou jogador já removido dá ErrSessionExpired, nunca
+// o estado de outra sala. Deve ser chamada com mu travado.
+func (st *ServerState) salaDaSessao(token string) (int, *Room, error) {
+	id := st.jogadorDa(token)
+	room, ok := st.playerRoom[id]
+	if !ok {
+		return 0, nil, errors.New(shared.ErrSessionExpired)
+	}
+	st.touch(id)
+	return id, room, nil
+}
+
+// Extensão dos arquivos que um cliente pode escolher como mapa
+const extensaoMapa = ".txt"
+
+// Máximo de salas, contando a padrão; salas nunca são removidas
+const maxSalas = 32
+
+// arquivoDoMapa resolve o nome de mapa pedido por um cliente. Só aceita um
+// nome simples de arquivo .txt na mesma pasta do mapa padrão: o conteúdo
+// volta para os clientes no Connect, então nada de código-fonte ou caminhos.
+func (st *ServerState) arquivoDoMapa(nome string) (string, bool) {
+	if nome == "" {
+		return st.mapaPadrao, true
+	}
+	if nome != filepath.Base(nome) || strings.HasPrefix(nome, ".") || filepath.Ext(nome) != extensaoMapa {
+		return "", false
+	}
+	return filepath.Join(filepath.Dir(st.mapaPadrao), nome), true
+}
+
+// ListRooms lista as salas existentes. É a única chamada de leitura sem
+// sessão: só expõe nomes, mapas e contagens, e o cliente a usa antes do Connect.
+func (s *GameService) ListRooms(args *shared.ListRoomsArgs, reply *shared.ListRoomsReply) error {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	for _, room := range s.state.rooms {
+		reply.Rooms = append(reply.Rooms, shared.RoomInfo{
+			Name:    room.nome,
+			Map:     filepath.Base(room.mapaArquivo),
+			Players: len(room.players),
+		})
+	}
+	slices.SortFunc(reply.Rooms, func(a, b shared.RoomInfo) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return nil
+}
+
+// CreateRoom cria uma nova sala com o mapa escolhido
+func (s *GameService) CreateRoom(args *shared.CreateRoomArgs, reply *shared.CreateRoomReply) error {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	id, _, err := s.state.salaDaSessao(args.SessionToken)
+	if err != nil {
+		return err
+	}
+	if args.Name == "" {
+		return errors.New(shared.ErrRoomNameEmpty)
+	}
+	if _, existe := s.state.rooms[args.Name]; existe {
+		return errors.New(shared.ErrRoomExists)
+	}
+	if len(s.state.rooms) >= maxSalas {
+		return errors.New(shared.ErrRoomLimit)
+	}
+
+	// Confere o mapa antes de registrar a criação no journal
+	arquivo, ok := s.state.arquivoDoMapa(args.Map)
+	if !ok {
+		logAviso("[Sala] Mapa %q recusado para %s", args.Map, args.Name)
+		return errors.New(shared.ErrRoomMapInvalid)
+	}
+	if _, err := carregarMapa(arquivo); err != nil {
+		logAviso("[Sala] Mapa %s inválido para %s: %v", arquivo, args.Name, err)
+		return errors.New(shared.ErrRoomMapInvalid)
+	}
+
+	if err := s.state.registrar(journalEntry{Op: opCreateRoom, Room: args.Name, Map: arquivo}); err != nil {
+		return err
+	}
+
+	logInfo("[RPC] CreateRoom -> %s por %s", args.Name, s.state.contaDe[id])
+	return nil
+}
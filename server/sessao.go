@@ -0,0 +1,44 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"jogo/shared"
+	"maps"
+)
+
+// novoToken gera um token de sessão aleatório
+func novoToken() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// jogadorDa retorna o ID do jogador dono do token, ou 0 se a sessão não existe.
+// Nenhum jogador tem ID 0, então o resultado pode ir direto para as buscas por ID.
+// Deve ser chamada com mu travado.
+func (st *ServerState) jogadorDa(token string) int {
+	return st.sessions[token]
+}
+
+// Reconnect devolve ao cliente o jogador associado ao token, se ele ainda
+// não foi removido pelo reaper (o idle-timeout é a janela de tolerância)
+func (s *GameService) Reconnect(args *shared.ReconnectArgs, reply *shared.ReconnectReply) error {
+	s.state.mu.Lock()
+	defer s.state.mu.Unlock()
+
+	id, ok := s.state.sessions[args.SessionToken]
+	if !ok || !s.state.touch(id) {
+		return errors.New(shared.ErrSessionExpired)
+	}
+
+	room := s.state.playerRoom[id]
+	reply.PlayerID = id
+	reply.LastSequenceNumber = room.lastSeqNums[id]
+	reply.AllPlayers = make(map[int]shared.PlayerState)
+	maps.Copy(reply.AllPlayers, room.players)
+
+	logInfo("[RPC] Reconnect -> ID: %d, último comando %d", id, reply.LastSequenceNumber)
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"math/rand"
+)
+
+// Direções usadas nas buscas em largura sobre o mapa
+var vizinhos = []Posicao{{0, -1}, {-1, 0}, {0, 1}, {1, 0}}
+
+// bloqueada verifica se (x, y) tem parede, pato ou inimigo. Deve ser chamada com mu travado.
+func (room *Room) bloqueada(x, y int) bool {
+	return room.mapa.tangivel(x, y) || room.noPato(x, y) || room.noInimigo(x, y)
+}
+
+// celulaLivre verifica se um jogador pode ficar em (x, y) sem colidir com
+// o mapa ou com outro jogador da sala (exceto ignorarID). Deve ser chamada com mu travado.
+func (room *Room) celulaLivre(x, y, ignorarID int) bool {
+	if !room.mapa.dentro(x, y) || room.bloqueada(x, y) {
+		return false
+	}
+	for id, p := range room.players {
+		// Jogadores mortos não ocupam a célula
+		if id != ignorarID && !p.Dead && p.PosX == x && p.PosY == y {
+			return false
+		}
+	}
+	return true
+}
+
+// escolherSpawn sorteia um ponto de nascimento livre do mapa. Se todos estão
+// ocupados (ou o mapa não tem nenhum), usa a célula livre mais próxima do
+// primeiro. Deve ser chamada com mu travado.
+func (room *Room) escolherSpawn() (Posicao, bool) {
+	var livres []Posicao
+	for _, p := range room.mapa.Spawns {
+		if room.celulaLivre(p.X, p.Y, 0) {
+			livres = append(livres, p)
+		}
+	}
+	if len(livres) > 0 {
+		return livres[rand.Intn(len(livres))], true
+	}
+
+	origem := Posicao{1, 1}
+	if len(room.mapa.Spawns) > 0 {
+		origem = room.mapa.Spawns[0]
+	}
+	return room.livreMaisProxima(origem)
+}
+
+// livreMaisProxima faz uma busca em largura a partir de origem até achar uma
+// célula livre. Deve ser chamada com mu travado.
+func (room *Room) livreMaisProxima(origem Posicao) (Posicao, bool) {
+	visitado := map[Posicao]bool{origem: true}
+	fila := []Posicao{origem}
+	for len(fila) > 0 {
+		p := fila[0]
+		fila = fila[1:]
+		if room.celulaLivre(p.X, p.Y, 0) {
+			return p, true
+		}
+		for _, d := range vizinhos {
+			v := Posicao{p.X + d.X, p.Y + d.Y}
+			// Atravessa jogadores mas não paredes, para não nascer do outro lado de uma
+			if !visitado[v] && room.mapa.dentro(v.X, v.Y) && !room.mapa.tangivel(v.X, v.Y) {
+				visitado[v] = true
+				fila = append(fila, v)
+			}
+		}
+	}
+	return Posicao{}, false
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"jogo/shared"
+	"time"
+)
+
+const (
+	damageCooldown = time.Second     // Tempo mínimo entre dois danos no mesmo jogador
+	respawnDelay   = 3 * time.Second // Tempo morto antes de renascer
+)
+
+// podeLevarDano verifica se o jogador está vivo e fora do intervalo de
+// proteção, e já marca o dano. Deve ser chamada com mu travado.
+func (room *Room) podeLevarDano(id int, agora time.Time) bool {
+	p, ok := room.players[id]
+	if !ok || p.Dead {
+		return false
+	}
+	if agora.Sub(room.ultimoDano[id]) < damageCooldown {
+		return false
+	}
+	room.ultimoDano[id] = agora
+	return true
+}
+
+// danoPorInimigos fere os jogadores vivos ao lado de algum inimigo.
+// Deve ser chamada com mu travado.
+func (st *ServerState) danoPorInimigos(room *Room) {
+	agora := time.Now()
+	for id, p := range room.players {
+		if p.Dead || !room.inimigoAoLado(p.PosX, p.PosY) {
+			continue
+		}
+		if room.podeLevarDano(id, agora) {
+			st.causarDano(room, id, false)
+		}
+	}
+}
+
+// causarDano registra o dano no jogador e, se ele morrer, sua sobrevivência
+// no placar. Só sincroniza o journal quando o dano vem de um comando do
+// jogador; o dano do tick dos inimigos é simulação. Deve ser chamada com mu travado.
+func (st *ServerState) causarDano(room *Room, id int, sincronizar bool) error {
+	desde, vivo := room.vivoDesde[id]
+	if err := st.gravarEAplicar(journalEntry{Op: opDamage, Room: room.nome, PlayerID: id}, sincronizar); err != nil {
+		return err
+	}
+	if vivo && room.players[id].Dead {
+		st.ranking.sobreviveu(st.contaDe[id], time.Since(desde))
+	}
+	return nil
+}
+
+// inimigoAoLado verifica se há um inimigo em (x, y) ou colado nele.
+// Deve ser chamada com mu travado.
+func (room *Room) inimigoAoLado(x, y int) bool {
+	if room.noInimigo(x, y) {
+		return true
+	}
+	for _, d := range vizinhos {
+		if room.noInimigo(x+d.X, y+d.Y) {
+			return true
+		}
+	}
+	return false
+}
+
+// ferir tira um ponto de vida do jogador e o mata se chegar a zero.
+// Deve ser chamada com mu travado.
+func (room *Room) ferir(id int) {
+	p, ok := room.players[id]
+	if !ok || p.Dead {
+		return
+	}
+	p.HP--
+	if p.HP <= 0 {
+		p.HP = 0
+		p.Dead = true
+		room.mortos[id] = time.Now()
+		delete(room.vivoDesde, id)
+		logInfo("[Vida] ID %d morreu na sala %s", id, room.nome)
+	}
+	room.players[id] = p
+	room.recordChange(id, changeMoved)
+}
+
+// renascer traz o jogador de volta em (x, y) com a vida cheia.
+// Deve ser chamada com mu travado.
+func (room *Room) renascer(id, x, y int) {
+	p, ok := room.players[id]
+	if !ok {
+		return
+	}
+	p.PosX, p.PosY = x, y
+	p.HP = shared.MaxHP
+	p.Dead = false
+	room.players[id] = p
+	delete(room.mortos, id)
+	room.vivoDesde[id] = time.Now()
+	room.recordChange(id, changeMoved)
+	logInfo("[Vida] ID %d renasceu em (%d, %d) na sala %s", id, x, y, room.nome)
+}
+
+// respawnManager renasce os jogadores mortos há mais de respawnDelay, conferindo a cada tick
+func (st *ServerState) respawnManager(tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		st.mu.Lock()
+		for _, room := range st.rooms {
+			for id, morte := range room.mortos {
+				if now.Sub(morte) < respawnDelay {
+					continue
+				}
+				if spawn, ok := room.escolherSpawn(); ok {
+					st.registrarSimulacao(journalEntry{Op: opRespawn, Room: room.nome, PlayerID: id, X: spawn.X, Y: spawn.Y})
+				}
+			}
+		}
+		st.mu.Unlock()
+	}
+}
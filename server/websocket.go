@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Implementação mínima do WebSocket (RFC 6455), só o que o espectador usa:
+// handshake, quadros de texto do servidor e leitura de close/ping do navegador.
+
+// GUID fixo da RFC, concatenado à chave do cliente no handshake
+const guidWebSocket = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// Opcodes usados
+const (
+	wsTexto = 0x1
+	wsClose = 0x8
+	wsPing  = 0x9
+	wsPong  = 0xA
+)
+
+// Maior quadro aceito do navegador; o espectador não manda nada além de controle
+const maxQuadroWS = 1 << 16
+
+// mesmaOrigem diz se o Origin do navegador aponta para o próprio host do
+// pedido. Sem essa conferência, qualquer site aberto pelo espectador poderia
+// abrir o WebSocket e ler o jogo. Clientes fora do navegador não mandam Origin.
+func mesmaOrigem(r *http.Request) bool {
+	origem := r.Header.Get("Origin")
+	if origem == "" {
+		return true
+	}
+	u, err := url.Parse(origem)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// cabecalhoTem verifica se algum valor do cabeçalho, separado por vírgulas, é valor
+func cabecalhoTem(h http.Header, nome, valor string) bool {
+	for _, v := range h.Values(nome) {
+		for parte := range strings.SplitSeq(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(parte), valor) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// aceitarWebSocket faz o handshake e devolve a conexão já fora do servidor HTTP
+func aceitarWebSocket(w http.ResponseWriter, r *http.Request) (net.Conn, *bufio.ReadWriter, error) {
+	chave := r.Header.Get("Sec-WebSocket-Key")
+	if !cabecalhoTem(r.Header, "Connection", "upgrade") || !cabecalhoTem(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" || chave == "" {
+		http.Error(w, "esperava um handshake WebSocket", http.StatusBadRequest)
+		return nil, nil, errors.New("handshake inválido")
+	}
+	if !mesmaOrigem(r) {
+		http.Error(w, "origem não permitida", http.StatusForbidden)
+		return nil, nil, errors.New("origem " + r.Header.Get("Origin") + " não permitida")
+	}
+
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	soma := sha1.Sum([]byte(chave + guidWebSocket))
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(soma[:]) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+	return conn, rw, nil
+}
+
+// escreverQuadroWS manda um quadro completo; o servidor não mascara
+func escreverQuadroWS(w *bufio.Writer, opcode byte, payload []byte) error {
+	cab := []byte{0x80 | opcode} // FIN: nunca fragmentamos
+	switch n := len(payload); {
+	case n < 126:
+		cab = append(cab, byte(n))
+	case n <= 0xFFFF:
+		cab = append(cab, 126)
+		cab = binary.BigEndian.AppendUint16(cab, uint16(n))
+	default:
+		cab = append(cab, 127)
+		cab = binary.BigEndian.AppendUint64(cab, uint64(n))
+	}
+	w.Write(cab)
+	w.Write(payload)
+	return w.Flush()
+}
+
+// lerQuadroWS lê um quadro do navegador. A RFC exige que ele venha mascarado;
+// um quadro sem máscara é erro e a conexão deve ser fechada.
+func lerQuadroWS(r *bufio.Reader) (byte, []byte, error) {
+	var cab [2]byte
+	if _, err := io.ReadFull(r, cab[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode := cab[0] & 0x0F
+	n := uint64(cab[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > maxQuadroWS {
+		return 0, nil, errors.New("quadro WebSocket grande demais")
+	}
+
+	if cab[1]&0x80 == 0 {
+		return 0, nil, errors.New("quadro WebSocket do cliente sem máscara")
+	}
+	var mascara [4]byte
+	if _, err := io.ReadFull(r, mascara[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mascara[i%4]
+	}
+	return opcode, payload, nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// quadroCliente monta um quadro como o navegador manda, mascarado ou não
+func quadroCliente(opcode byte, payload []byte, mascarar bool) []byte {
+	q := []byte{0x80 | opcode, byte(len(payload))}
+	if !mascarar {
+		return append(q, payload...)
+	}
+	mascara := []byte{1, 2, 3, 4}
+	q[1] |= 0x80
+	q = append(q, mascara...)
+	for i, b := range payload {
+		q = append(q, b^mascara[i%4])
+	}
+	return q
+}
+
+func TestLerQuadroWS(t *testing.T) {
+	r := bufio.NewReader(bytes.NewReader(quadroCliente(wsPing, []byte("oi"), true)))
+	op, payload, err := lerQuadroWS(r)
+	if err != nil || op != wsPing || string(payload) != "oi" {
+		t.Fatalf("lerQuadroWS = %x, %q, %v; esperava ping com \"oi\"", op, payload, err)
+	}
+
+	r = bufio.NewReader(bytes.NewReader(quadroCliente(wsPing, []byte("oi"), false)))
+	if _, _, err := lerQuadroWS(r); err == nil {
+		t.Fatal("quadro sem máscara aceito")
+	}
+}
+
+func TestAceitarWebSocketOrigem(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := aceitarWebSocket(w, r)
+		if err == nil {
+			conn.Close()
+		}
+	}))
+	defer srv.Close()
+	host := srv.Listener.Addr().String()
+
+	casos := []struct {
+		nome   string
+		origem string
+		status int
+	}{
+		{"mesma origem", "http://" + host, http.StatusSwitchingProtocols},
+		{"sem Origin", "", http.StatusSwitchingProtocols},
+		{"outro site", "https://exemplo.com", http.StatusForbidden},
+		{"outra porta", "http://127.0.0.1:1", http.StatusForbidden},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", srv.URL, nil)
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+			req.Header.Set("Sec-WebSocket-Version", "13")
+			req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+			if c.origem != "" {
+				req.Header.Set("Origin", c.origem)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != c.status {
+				t.Errorf("status = %d, esperava %d", resp.StatusCode, c.status)
+			}
+		})
+	}
+}
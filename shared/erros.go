@@ -0,0 +1,72 @@
+package shared
+
+import (
+	"errors"
+	"strings"
+)
+
+// Versão do contrato entre cliente e servidor. Deve ser incrementada
+// sempre que um tipo deste pacote mudar de forma incompatível.
+const ProtocolVersion = 2
+
+// Motivo pelo qual o servidor recusou uma conexão
+type RejectReason string
+
+const (
+	RejectServerFull       RejectReason = "server_full"
+	RejectBanned           RejectReason = "banned"
+	RejectProtocolMismatch RejectReason = "protocol_mismatch"
+	RejectRoomNotFound     RejectReason = "room_not_found"
+	RejectBadCredentials   RejectReason = "bad_credentials"
+)
+
+// Prefixo que identifica um RejectError na mensagem de erro
+const rejectPrefix = "conexão recusada ["
+
+// RejectError é o erro devolvido pelo Connect quando o jogador não pode entrar.
+// O net/rpc só transporta a mensagem de erro, então o motivo vai codificado
+// nela e ParseRejectError o recupera do lado do cliente.
+type RejectError struct {
+	Reason RejectReason
+	Detail string // Explicação para humanos, pode mudar à vontade
+}
+
+func (e *RejectError) Error() string {
+	return rejectPrefix + string(e.Reason) + "] " + e.Detail
+}
+
+// Is faz errors.Is comparar só o motivo, ignorando o detalhe
+func (e *RejectError) Is(alvo error) bool {
+	t, ok := alvo.(*RejectError)
+	return ok && e != nil && t != nil && t.Reason == e.Reason
+}
+
+// Valores para comparar com errors.Is
+var (
+	ErrServerFull       = &RejectError{Reason: RejectServerFull}
+	ErrBanned           = &RejectError{Reason: RejectBanned}
+	ErrProtocolMismatch = &RejectError{Reason: RejectProtocolMismatch}
+	ErrRoomNotFound     = &RejectError{Reason: RejectRoomNotFound}
+	ErrBadCredentials   = &RejectError{Reason: RejectBadCredentials}
+)
+
+// ParseRejectError reconstrói o RejectError a partir do erro recebido pelo
+// cliente (normalmente um rpc.ServerError). Retorna nil se err não é um.
+func ParseRejectError(err error) *RejectError {
+	if err == nil {
+		return nil
+	}
+	var re *RejectError
+	if errors.As(err, &re) {
+		return re
+	}
+	resto, ok := strings.CutPrefix(err.Error(), rejectPrefix)
+	if !ok {
+		return nil
+	}
+	motivo, detalhe, ok := strings.Cut(resto, "] ")
+	if !ok {
+		return nil
+	}
+	return &RejectError{Reason: RejectReason(motivo), Detail: detalhe}
+}
@@ -0,0 +1,14 @@
+package shared
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+)
+
+// PinCertificado é o SHA-256 da chave pública do certificado, em base64.
+// Continua o mesmo se o certificado for renovado com a mesma chave.
+func PinCertificado(cert *x509.Certificate) string {
+	soma := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(soma[:])
+}
@@ -1,44 +1,302 @@
 package shared
 
+// Vida de um jogador ao entrar ou renascer
+const MaxHP = 3
+
 // Estado do jogador
 type PlayerState struct {
-	PosX int
-	PosY int
+	PosX  int  `json:"pos_x"`
+	PosY  int  `json:"pos_y"`
+	Score int  `json:"score"` // Moedas coletadas
+	HP    int  `json:"hp"`    // Pontos de vida
+	Dead  bool `json:"dead"`  // Morto, esperando para renascer
+	Warps int  `json:"warps"` // Incrementa quando o servidor move o jogador por conta própria
+}
+
+// Estado da moeda de uma sala
+type CoinState struct {
+	Active      bool `json:"active"`
+	X           int  `json:"x"`
+	Y           int  `json:"y"`
+	LastTakenBy int  `json:"last_taken_by"` // Último jogador que pegou uma moeda, 0 se ninguém
+}
+
+// Estado do portal de uma sala
+type PortalState struct {
+	Active     bool `json:"active"`
+	X          int  `json:"x"`
+	Y          int  `json:"y"`
+	LastUsedBy int  `json:"last_used_by"` // Último jogador que atravessou o portal, 0 se expirou
+}
+
+// Estado de um inimigo
+type EnemyState struct {
+	X       int  `json:"x"`
+	Y       int  `json:"y"`
+	DirX    int  `json:"dir_x"` // Direção da patrulha
+	DirY    int  `json:"dir_y"`
+	Chasing bool `json:"chasing"` // Perseguindo um jogador
+}
+
+// Estado do pato de uma sala
+type DuckState struct {
+	Present  bool `json:"present"` // false se o mapa da sala não tem pato
+	X        int  `json:"x"`
+	Y        int  `json:"y"`
+	Stopped  bool `json:"stopped"`   // Parado por carinho ou porque o portal fechou
+	PettedBy int  `json:"petted_by"` // Último jogador que fez carinho, 0 se ninguém
 }
 
 // Contrato que o cliente manda para se conectar com o servidor
-type ConnectArgs struct{}
+type ConnectArgs struct {
+	Username string `json:"username"` // Conta criada com Register
+	Password string `json:"password"`
+	Room     string `json:"room"`     // Sala desejada; vazio usa a sala padrão
+	Protocol int    `json:"protocol"` // ProtocolVersion do cliente
+}
+
+// Contrato para criar uma conta
+type RegisterArgs struct {
+	Username string `json:"username"` // 3 a 20 letras, números, _ ou -
+	Password string `json:"password"` // Pelo menos MinPasswordLen caracteres
+}
+
+// Resposta do servidor ao cadastro
+type RegisterReply struct{}
+
+// Tamanho mínimo da senha de uma conta
+const MinPasswordLen = 8
 
 // Resposta do servidor ao conectar um novo jogador
 type ConnectReply struct {
-	PlayerID   int
-	AllPlayers map[int]PlayerState // Todos os jogadores, incluindo o novo
+	PlayerID     int                 `json:"player_id"`
+	SessionToken string              `json:"session_token"` // Opaco, identifica o jogador em todas as outras chamadas
+	Room         string              `json:"room"`          // Sala em que o jogador entrou
+	PosX         int                 `json:"pos_x"`         // Ponto de nascimento escolhido pelo servidor
+	PosY         int                 `json:"pos_y"`
+	MapLines     []string            `json:"map_lines"`   // Mapa da sala, linha por linha
+	MapVersion   int                 `json:"map_version"` // Versão do mapa, ver StateDelta
+	AllPlayers   map[int]PlayerState `json:"all_players"` // Todos os jogadores da sala, incluindo o novo
+}
+
+// Contrato para voltar ao jogo depois de perder a conexão
+type ReconnectArgs struct {
+	SessionToken string `json:"session_token"`
 }
 
+// Resposta do servidor à reconexão
+type ReconnectReply struct {
+	PlayerID           int                 `json:"player_id"`
+	LastSequenceNumber int                 `json:"last_sequence_number"` // Último comando processado, o cliente continua a partir dele
+	AllPlayers         map[int]PlayerState `json:"all_players"`
+}
+
+// Mensagens de erro devolvidas pelo servidor. As recusas do Connect
+// são RejectError, ver erros.go.
+const (
+	ErrSessionExpired  = "sessão inválida ou expirada"
+	ErrRoomExists      = "sala já existe"
+	ErrRoomNameEmpty   = "nome da sala vazio"
+	ErrRoomMapInvalid  = "mapa da sala inválido"
+	ErrRoomLimit       = "limite de salas do servidor atingido"
+	ErrNoSpawn         = "nenhuma posição livre para nascer"
+	ErrChatEmpty       = "mensagem vazia"
+	ErrUsernameTaken   = "nome de usuário já existe"
+	ErrUsernameBad     = "nome de usuário inválido: use de 3 a 20 letras, números, _ ou -"
+	ErrPasswordShort   = "senha curta demais"
+	ErrAccountLimit    = "limite de contas do servidor atingido"
+	ErrRegisterTooFast = "cadastros demais deste endereço, tente de novo em alguns minutos"
+)
+
 // Contrato para atualizar o estado do jogador
 type UpdateStateArgs struct {
-	PlayerID       int
-	NewX           int
-	NewY           int
-	SequenceNumber int
+	SessionToken   string `json:"session_token"`
+	NewX           int    `json:"new_x"`
+	NewY           int    `json:"new_y"`
+	SequenceNumber int    `json:"sequence_number"`
 }
 
+// Motivo pelo qual o servidor recusou um movimento
+type MoveRejection string
+
+const (
+	MoveOK          MoveRejection = ""
+	MoveUnknown     MoveRejection = "jogador desconhecido"
+	MoveTooFar      MoveRejection = "movimento maior que uma célula"
+	MoveOutOfBounds MoveRejection = "fora do mapa"
+	MoveBlocked     MoveRejection = "célula bloqueada"
+	MoveOccupied    MoveRejection = "célula ocupada por outro jogador"
+	MoveDead        MoveRejection = "jogador morto"
+	MoveRateLimited MoveRejection = "movimentos rápidos demais"
+)
+
 // Resposta do servidor à atualização de estado
-type UpdateStateReply struct{}
+type UpdateStateReply struct {
+	Accepted bool          `json:"accepted"`
+	Reason   MoveRejection `json:"reason"` // Preenchido quando Accepted é false
+	PosX     int           `json:"pos_x"`  // Posição autoritativa do jogador após o comando
+	PosY     int           `json:"pos_y"`  // (diferente da pedida se recusado ou teletransportado)
+}
 
 // Contrato para obter o estado de todos os jogadores
-type GetStateArgs struct{}
+type GetStateArgs struct {
+	SessionToken string `json:"session_token"` // Quem está pedindo, conta como sinal de vida
+}
 
 // Resposta do servidor com o estado de todos os jogadores
 type GetStateReply struct {
-	AllPlayers map[int]PlayerState
+	Version    int64               `json:"version"` // Versão do estado no servidor
+	AllPlayers map[int]PlayerState `json:"all_players"`
+}
+
+// Contrato para esperar até o estado mudar além de uma versão conhecida
+type WaitForStateArgs struct {
+	SessionToken string `json:"session_token"`
+	SinceVersion int64  `json:"since_version"` // Última versão que o cliente já conhece
+}
+
+// Resposta do long-poll; se Version == SinceVersion, expirou sem mudanças
+type WaitForStateReply struct {
+	StateDelta
+}
+
+// Mudanças no conjunto de jogadores entre duas versões do estado.
+// Se Full for true, o cliente estava muito atrás e AllPlayers traz o estado completo.
+type StateDelta struct {
+	Version    int64               `json:"version"`
+	Full       bool                `json:"full"`
+	AllPlayers map[int]PlayerState `json:"all_players"` // Só quando Full
+	Joined     map[int]PlayerState `json:"joined"`      // Jogadores que entraram
+	Moved      map[int]PlayerState `json:"moved"`       // Jogadores que já existiam e mudaram
+	Left       []int               `json:"left"`        // Jogadores que saíram
+	Coin       CoinState           `json:"coin"`        // Sempre o estado atual da moeda
+	Portal     PortalState         `json:"portal"`      // Sempre o estado atual do portal
+	Duck       DuckState           `json:"duck"`        // Sempre o estado atual do pato
+	Enemies    []EnemyState        `json:"enemies"`     // Sempre as posições atuais dos inimigos
+	ChatSeq    int64               `json:"chat_seq"`    // Número da última mensagem do chat da sala
+	MapVersion int                 `json:"map_version"` // Muda quando o servidor recarrega o mapa
+	MapLines   []string            `json:"map_lines"`   // O mapa da sala, quando Full ou se mudou desde SinceVersion
+}
+
+// Contrato para obter só as mudanças desde uma versão conhecida
+type GetStateDeltaArgs struct {
+	SessionToken string `json:"session_token"`
+	SinceVersion int64  `json:"since_version"`
+}
+
+// Resposta do servidor com as mudanças desde SinceVersion
+type GetStateDeltaReply struct {
+	StateDelta
 }
 
 // Contrato para desconectar um jogador
 type DisconnectArgs struct {
-	PlayerID       int
-	SequenceNumber int
+	SessionToken   string `json:"session_token"`
+	SequenceNumber int    `json:"sequence_number"`
 }
 
 // Resposta do servidor à desconexão
 type DisconnectReply struct{}
+
+// Contrato do heartbeat, mantém o jogador vivo no servidor
+type HeartbeatArgs struct {
+	SessionToken string `json:"session_token"`
+}
+
+// Resposta do servidor ao heartbeat
+type HeartbeatReply struct {
+	Known bool `json:"known"` // false se o servidor já removeu o jogador
+}
+
+// Resumo de uma sala
+type RoomInfo struct {
+	Name    string `json:"name"`
+	Map     string `json:"map"`
+	Players int    `json:"players"`
+}
+
+// Contrato para listar as salas. Não pede sessão: o cliente lista as
+// salas antes do Connect, para sugerir uma quando a escolhida não existe.
+type ListRoomsArgs struct{}
+
+// Resposta do servidor com as salas existentes
+type ListRoomsReply struct {
+	Rooms []RoomInfo `json:"rooms"`
+}
+
+// Contrato para criar uma sala
+type CreateRoomArgs struct {
+	SessionToken string `json:"session_token"` // Só jogadores conectados criam salas
+	Name         string `json:"name"`
+	Map          string `json:"map"` // Arquivo .txt na pasta de mapas do servidor; vazio usa o padrão
+}
+
+// Resposta do servidor à criação de sala
+type CreateRoomReply struct{}
+
+// Recordes de uma conta, somando todas as vezes que ela jogou
+type LeaderboardEntry struct {
+	Username         string `json:"username"`
+	Coins            int    `json:"coins"`              // Moedas coletadas no total
+	BestSurvivalSecs int    `json:"best_survival_secs"` // Vida mais longa, em segundos
+	Portals          int    `json:"portals"`            // Portais atravessados no total
+}
+
+// Critérios de ordenação do placar
+const (
+	LeaderboardByCoins    = "coins"
+	LeaderboardBySurvival = "survival"
+	LeaderboardByPortals  = "portals"
+)
+
+// Contrato para obter o placar
+type GetLeaderboardArgs struct {
+	SessionToken string `json:"session_token"`
+	OrderBy      string `json:"order_by"` // Um dos LeaderboardBy*; vazio ordena por moedas
+	Limit        int    `json:"limit"`    // Quantos jogadores; 0 usa o padrão do servidor
+}
+
+// Resposta do servidor com o placar
+type GetLeaderboardReply struct {
+	Entries []LeaderboardEntry `json:"entries"`
+}
+
+// Contrato para interagir com o pato
+type InteractArgs struct {
+	SessionToken string `json:"session_token"`
+}
+
+// Resposta do servidor à interação
+type InteractReply struct {
+	Petted bool `json:"petted"` // true se o jogador estava ao lado do pato
+}
+
+// Uma mensagem do chat da sala
+type ChatMessage struct {
+	Seq      int64  `json:"seq"` // Crescente dentro da sala
+	PlayerID int    `json:"player_id"`
+	Text     string `json:"text"`
+}
+
+// Contrato para mandar uma mensagem no chat
+type SendChatArgs struct {
+	SessionToken string `json:"session_token"`
+	Text         string `json:"text"`
+}
+
+// Resposta do servidor ao envio
+type SendChatReply struct {
+	Seq int64 `json:"seq"` // Número dado à mensagem
+}
+
+// Contrato para buscar as mensagens do chat
+type GetChatArgs struct {
+	SessionToken string `json:"session_token"`
+	Since        int64  `json:"since"` // Última mensagem que o cliente já tem
+}
+
+// Resposta do servidor com as mensagens depois de Since, em ordem.
+// Mensagens antigas demais já saíram do buffer e não voltam.
+type GetChatReply struct {
+	Messages []ChatMessage `json:"messages"`
+}